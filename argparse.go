@@ -1,12 +1,16 @@
 package argparse
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"os"
 	"strconv"
 	"strings"
 	"bytes"
 	"path/filepath"
+	"sort"
+	"time"
 )
 
 // Author: Zer1t0
@@ -14,8 +18,6 @@ import (
 /*
 Things to add:
 - Basic types
-- Maps
-- Arrays
 */
 
 const pARAMPREFIX = '-'
@@ -30,6 +32,7 @@ const (
 	ActionHelp
 	ActionStoreConst
 	ActionIncrement
+	ActionAppend
 	actionend
 )
 
@@ -51,8 +54,37 @@ const (
 	ShortcutEqCategory // Ex: -z=
 	ShortcutGroupEqCategory // Ex: -xyz=
 	ValueCategory // any other value
+	UnknownCategory // Ex: --zeta when it is not defined
+	TerminatorCategory // Ex: -- (the rest are values)
 )
 
+// Source of the value of an argument, see IsSet and Source
+type Source int
+
+const (
+	SourceDefault Source = iota // not given, the default value is used
+	SourceConst // given with ActionStoreConst, the const value is used
+	SourceEnvironment
+	SourceConfigFile
+	SourceCommandLine
+)
+
+func (source Source) String() string {
+	switch source {
+		case SourceDefault:
+			return "default"
+		case SourceConst:
+			return "const"
+		case SourceEnvironment:
+			return "environment"
+		case SourceConfigFile:
+			return "config file"
+		case SourceCommandLine:
+			return "command line"
+	}
+	return fmt.Sprintf("Source(%d)", int(source))
+}
+
 
 
 // Util functions
@@ -60,6 +92,25 @@ func isLetter(char rune) bool {
 	return (char >= 'A' && char <= 'Z') || (char >= 'a' && char <= 'z')
 }
 
+func isDigit(char rune) bool {
+	return char >= '0' && char <= '9'
+}
+
+// isNegativeNumber checks if value looks like a negative number, as -5,
+// -.5 or -1.5e3
+func isNegativeNumber(value string) bool {
+	if len(value) < 2 || value[0] != '-' {
+		return false
+	}
+
+	if !isDigit(rune(value[1])) && value[1] != '.' {
+		return false
+	}
+
+	_, err := strconv.ParseFloat(value, 64)
+	return err == nil
+}
+
 func isValidGroupName(name string) bool {
 
 	if len(name) == 0 {
@@ -114,6 +165,135 @@ func isValidName(name string) bool {
 	return hasLetters
 }
 
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// editDistance returns the optimal string alignment distance between a and
+// b, that is the Levenshtein distance counting transpositions as one edit
+func editDistance(a string, b string) int {
+	ra := []rune(a)
+	rb := []rune(b)
+
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j] + 1, d[i][j-1] + 1, d[i-1][j-1] + cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2] + 1)
+			}
+		}
+	}
+
+	return d[len(ra)][len(rb)]
+}
+
+// closestNames returns the candidates within maxDistance of name, the
+// closest first
+func closestNames(name string, candidates []string, maxDistance int) []string {
+	if maxDistance <= 0 {
+		return nil
+	}
+
+	distances := map[string]int{}
+	for _, candidate := range candidates {
+		distance := editDistance(name, candidate)
+		if distance <= maxDistance {
+			distances[candidate] = distance
+		}
+	}
+
+	names := make([]string, 0, len(distances))
+	for candidate := range distances {
+		names = append(names, candidate)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if distances[names[i]] != distances[names[j]] {
+			return distances[names[i]] < distances[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	return names
+}
+
+func joinInts(values []int) string {
+	strs := make([]string, 0, len(values))
+	for _, v := range values {
+		strs = append(strs, fmt.Sprint(v))
+	}
+	return strings.Join(strs, ",")
+}
+
+// splitPairs splits a comma-separated list of key<separator>value pairs.
+func splitPairs(value string, separator string) ([][2]string, error) {
+	pairs := make([][2]string, 0, 4)
+
+	for _, item := range strings.Split(value, ",") {
+		kv := strings.SplitN(item, separator, 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("must be a list of key%svalue pairs", separator)
+		}
+		pairs = append(pairs, [2]string{kv[0], kv[1]})
+	}
+
+	return pairs, nil
+}
+
+func joinStringMap(values map[string]string, separator string) string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k + separator + values[k])
+	}
+	return strings.Join(pairs, ",")
+}
+
+func joinIntMap(values map[string]int, separator string) string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s%s%d", k, separator, values[k]))
+	}
+	return strings.Join(pairs, ",")
+}
+
 func isValidShortcut(char rune) bool {
 	return char == 0 || isLetter(char)
 }
@@ -138,9 +318,159 @@ func isPositionalName(name string) bool {
 
 
 
+// ==ERRORS==
+
+// ErrHelp is returned by Parse when the help argument was given, the help
+// message is stored in the help argument
+var ErrHelp = errors.New("Help")
+
+// ErrCompletion is returned by Parse when it was called by a completion
+// script with the completion command, see SetCompletionCommand, the
+// candidates are already printed and the program must exit with status 0
+// without printing anything else
+var ErrCompletion = errors.New("Completion")
+
+// argDisplay formats an argument as name[-s] for error messages
+func argDisplay(name string, shortcut rune) string {
+	if shortcut == NOSHORTCUT {
+		return name
+	}
+	if name == "" {
+		return fmt.Sprintf("-%c", shortcut)
+	}
+	return fmt.Sprintf("%s[-%c]", name, shortcut)
+}
+
+// InvalidValueError is returned when a value cannot be set to an argument
+type InvalidValueError struct {
+	Value    string
+	Argument string
+	Shortcut rune
+	Parser   string // path of the parser, Ex: "tool remote add"
+	Reason   string // Ex: "must be an integer"
+}
+
+func invalidValue(value string, name string, shortcut rune, reason string) error {
+	return &InvalidValueError{Value: value, Argument: name, Shortcut: shortcut, Reason: reason}
+}
+
+func (err *InvalidValueError) Error() string {
+	return fmt.Sprintf("Invalid value \"%s\" for argument %s, %s", err.Value, argDisplay(err.Argument, err.Shortcut), err.Reason)
+}
+
+// MissingValueError is returned when an argument that requires a value is
+// the last one
+type MissingValueError struct {
+	Argument string
+	Shortcut rune
+	Parser   string
+}
+
+func (err *MissingValueError) Error() string {
+	return fmt.Sprintf("No value for argument %s", argDisplay(err.Argument, err.Shortcut))
+}
+
+// MissingArgumentError is returned when a mandatory or positional argument
+// was not given
+type MissingArgumentError struct {
+	Argument string
+	Shortcut rune
+	Parser   string
+}
+
+func (err *MissingArgumentError) Error() string {
+	return fmt.Sprintf("argument %s has no value", argDisplay(err.Argument, err.Shortcut))
+}
+
+// RequiredGroupError is returned when no argument of a required group was
+// given
+type RequiredGroupError struct {
+	Group     string
+	Parser    string
+	Arguments []string // arguments of the group
+}
+
+func (err *RequiredGroupError) Error() string {
+	return fmt.Sprintf("no argument from group \"%s\" (%s) was specified", err.Group, strings.Join(err.Arguments, ","))
+}
+
+// ExclusiveGroupError is returned when more than one argument of an
+// exclusive group was given
+type ExclusiveGroupError struct {
+	Group     string
+	Parser    string
+	Arguments []string // arguments of the group that were given
+}
+
+func (err *ExclusiveGroupError) Error() string {
+	return fmt.Sprintf("more than one argument of group \"%s\" was specified (%s)", err.Group, strings.Join(err.Arguments, ","))
+}
+
+// UnrecognizedArgumentError is returned by Parse when an argument looks
+// like a flag but it is not defined in the parser
+type UnrecognizedArgumentError struct {
+	Argument    string
+	Parser      string
+	Suggestions []string // defined arguments with a similar name
+}
+
+func (err *UnrecognizedArgumentError) Error() string {
+	if len(err.Suggestions) > 0 {
+		return fmt.Sprintf("unrecognized argument %s, did you mean %s?", err.Argument, strings.Join(err.Suggestions, " or "))
+	}
+	return fmt.Sprintf("unrecognized argument %s", err.Argument)
+}
+
+// UnknownSubcommandError is returned by Parse when the parser has
+// subparsers and the given subcommand is not one of them
+type UnknownSubcommandError struct {
+	Subcommand  string
+	Parser      string
+	Suggestions []string // subcommands with a similar name
+}
+
+func (err *UnknownSubcommandError) Error() string {
+	if len(err.Suggestions) > 0 {
+		return fmt.Sprintf("unknown subcommand %s, did you mean %s?", err.Subcommand, strings.Join(err.Suggestions, " or "))
+	}
+	return fmt.Sprintf("unknown subcommand %s", err.Subcommand)
+}
+
+// CommandError wraps an error of a subcommand with the path of the
+// subcommand, Ex: "tool remote add: argument --url has no value"
+type CommandError struct {
+	Path string
+	Err  error
+}
+
+func (err *CommandError) Error() string {
+	return fmt.Sprintf("%s: %s", err.Path, err.Err)
+}
+
+func (err *CommandError) Unwrap() error {
+	return err.Err
+}
+
+// MissingSubcommandError is returned by Parse when a subcommand is required
+// and none was given
+type MissingSubcommandError struct {
+	Parser      string
+	Subcommands []string
+}
+
+func (err *MissingSubcommandError) Error() string {
+	return fmt.Sprintf("no subcommand was specified (%s)", strings.Join(err.Subcommands, ","))
+}
+
+
 // ==INTERFACE value==
 type value interface{
     get() string
+    getDefault() string
+    getChoices() []string
+    typeName() string
+    getRaw() interface{}
+    clone() value
     set(string, string, rune) error
     setDefault()
     setTrue() error
@@ -157,6 +487,7 @@ type intValue struct {
     defaultValue int
     value        *int
     checkValue	IntArgCheckFunc
+    choices      []int
 }
 
 func newIntValue(defaultValue int, constValue int, checkValue IntArgCheckFunc) intValue{
@@ -178,13 +509,17 @@ func (val intValue) get() string {
 func (val intValue) set(value string, name string, shortcut rune) error {
     vali, err := strconv.ParseInt(value, 0, 0)
     if err != nil {
-        return fmt.Errorf("Invalid value \"%s\" for argument %s[-%c], must be an integer", value, name, shortcut)
+        return invalidValue(value, name, shortcut, "must be an integer")
     }
     valInt := int(vali)
 
+    if len(val.choices) > 0 && !containsInt(val.choices, valInt) {
+        return invalidValue(value, name, shortcut, fmt.Sprintf("must be one of %s", joinInts(val.choices)))
+    }
+
     if val.checkValue != nil{
     	if !val.checkValue(valInt){
-    		return fmt.Errorf("Invalid value \"%s\" for argument %s[-%c], must meet custom restriction", value, name, shortcut)
+    		return invalidValue(value, name, shortcut, "must meet custom restriction")
     	}
     }
 
@@ -192,6 +527,34 @@ func (val intValue) set(value string, name string, shortcut rune) error {
     return nil
 }
 
+func (val intValue) getDefault() string {
+	if val.defaultValue == 0 {
+		return ""
+	}
+	return fmt.Sprint(val.defaultValue)
+}
+
+func (val intValue) typeName() string {
+	return "int"
+}
+
+func (val intValue) getChoices() []string {
+	if len(val.choices) == 0 {
+		return nil
+	}
+	return strings.Split(joinInts(val.choices), ",")
+}
+
+func (val intValue) getRaw() interface{} {
+	return *(val.value)
+}
+
+func (val intValue) clone() value {
+	c := newIntValue(val.defaultValue, val.constValue, val.checkValue)
+	c.choices = val.choices
+	return c
+}
+
 func (val intValue) setDefault() {
 	*(val.value) = val.defaultValue
 }
@@ -224,6 +587,7 @@ type stringValue struct{
     defaultValue string
     value        *string
     checkValue StringArgCheckFunc
+    choices      []string
 }
 
 func newStringValue(defaultValue string, constValue string, checkValue StringArgCheckFunc) stringValue{
@@ -243,10 +607,14 @@ func (arg stringValue) get() string {
 }
 
 func (val stringValue) set(value string, name string, shortcut rune) error {
-    
+
+    if len(val.choices) > 0 && !containsString(val.choices, value) {
+        return invalidValue(value, name, shortcut, fmt.Sprintf("must be one of %s", strings.Join(val.choices, ",")))
+    }
+
     if val.checkValue != nil{
     	if !val.checkValue(value){
-    		return fmt.Errorf("Invalid value \"%s\" for argument %s[-%c], must meet custom restriction", value, name, shortcut)
+    		return invalidValue(value, name, shortcut, "must meet custom restriction")
     	}
     }
 
@@ -254,6 +622,28 @@ func (val stringValue) set(value string, name string, shortcut rune) error {
     return nil
 }
 
+func (val stringValue) getDefault() string {
+	return val.defaultValue
+}
+
+func (val stringValue) typeName() string {
+	return "string"
+}
+
+func (val stringValue) getChoices() []string {
+	return val.choices
+}
+
+func (val stringValue) getRaw() interface{} {
+	return *(val.value)
+}
+
+func (val stringValue) clone() value {
+	c := newStringValue(val.defaultValue, val.constValue, val.checkValue)
+	c.choices = val.choices
+	return c
+}
+
 func (val stringValue) setDefault() {
 	*(val.value) = val.defaultValue
 }
@@ -307,13 +697,13 @@ func (val boolValue) get() string {
 func (val boolValue) set(value string, name string, shortcut rune) error {
 	valb, err := strconv.ParseBool(value)
 	if err != nil {
-		return fmt.Errorf("Invalid value \"%s\" for argument %s[-%c], must be a boolean", value, name, shortcut)
+		return invalidValue(value, name, shortcut, "must be a boolean")
 	}
 
 	valBool := bool(valb)
 	if val.checkValue != nil{
     	if !val.checkValue(valBool){
-    		return fmt.Errorf("Invalid value \"%s\" for argument %s[-%c], must meet custom restriction", value, name, shortcut)
+    		return invalidValue(value, name, shortcut, "must meet custom restriction")
     	}
     }
 
@@ -321,6 +711,29 @@ func (val boolValue) set(value string, name string, shortcut rune) error {
 	return nil
 }
 
+func (val boolValue) getDefault() string {
+	if !val.defaultValue {
+		return ""
+	}
+	return fmt.Sprint(val.defaultValue)
+}
+
+func (val boolValue) typeName() string {
+	return "bool"
+}
+
+func (val boolValue) getChoices() []string {
+	return nil
+}
+
+func (val boolValue) getRaw() interface{} {
+	return *(val.value)
+}
+
+func (val boolValue) clone() value {
+	return newBoolValue(val.defaultValue, val.constValue, val.checkValue)
+}
+
 func (val boolValue) setDefault() {
 	*(val.value) = val.defaultValue
 }
@@ -347,547 +760,1919 @@ func (val boolValue) increment() error{
 // ==CLASS boolValue END==
 
 
-// ==CLASS argument BEGIN==
-type argument struct{
-    name         string
-    shortcut     rune
-    description  string
-    action       int
-    mandatory    bool
-    positional   bool
-    val          value
+// ==CLASS floatValue BEGIN==
+type FloatArgCheckFunc func(float64) bool
+
+type floatValue struct {
+	constValue   float64
+	defaultValue float64
+	value        *float64
+	checkValue   FloatArgCheckFunc
 }
 
-// CONSTRUCTORS of argument
-func  newArgument(name string, shortcut rune, description string, mandatory bool) *argument {
-	
-	var arg = new(argument)
+func newFloatValue(defaultValue float64, constValue float64, checkValue FloatArgCheckFunc) floatValue{
+	val := floatValue{}
+	val.defaultValue = defaultValue
+	val.constValue = constValue
+	val.value = new(float64)
+	val.checkValue = checkValue
 
-	arg.name = strings.ToLower(name)
-	arg.shortcut = shortcut
-	arg.description = description
-	arg.action = ActionStoreValue
-	arg.mandatory = mandatory
-	arg.positional = isPositionalName(name)
+	val.setDefault()
 
-	return arg
+	return val
 }
 
+func (val floatValue) get() string {
+	return fmt.Sprint(*(val.value))
+}
 
-// PUBLIC METHODS OF arguments
-func (arg argument) usage() string {
-
-	if arg.positional {
-		return fmt.Sprintf("%s", arg.name)
+func (val floatValue) set(value string, name string, shortcut rune) error {
+	valf, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return invalidValue(value, name, shortcut, "must be a float")
 	}
 
-	if arg.name != ""{
-		if arg.shortcut != NOSHORTCUT {
-			if arg.action == ActionStoreValue {
-				return fmt.Sprintf("%s/-%c %s", arg.name, arg.shortcut, strings.ToUpper(arg.name[2:]))
-			} else {
-				return fmt.Sprintf("%s/-%c", arg.name, arg.shortcut)
-			}
-		} else {
-			if arg.action == ActionStoreValue {
-				return fmt.Sprintf("%s %s", arg.name, strings.ToUpper(arg.name[2:]))
-			} else {
-				return fmt.Sprintf("%s", arg.name)
-			}
-		}
-	} else {
-		if arg.action == ActionStoreValue {
-			return fmt.Sprintf("-%c %s", arg.shortcut, strings.ToUpper(string(arg.shortcut)))
-		} else {
-			return fmt.Sprintf("-%c", arg.shortcut)
+	if val.checkValue != nil{
+		if !val.checkValue(valf){
+			return invalidValue(value, name, shortcut, "must meet custom restriction")
 		}
 	}
+
+	*(val.value) = valf
+	return nil
 }
 
-func (arg argument) help() string{
-	return fmt.Sprintf("%s\t%s", arg.usage(), arg.description)
+func (val floatValue) getDefault() string {
+	if val.defaultValue == 0 {
+		return ""
+	}
+	return fmt.Sprint(val.defaultValue)
 }
 
-func (arg argument) set(value string) error {
-    return arg.val.set(value, arg.name, arg.shortcut)
+func (val floatValue) typeName() string {
+	return "float"
 }
 
-func (arg argument) setDefault() {
-	arg.val.setDefault()
+func (val floatValue) getChoices() []string {
+	return nil
 }
 
-func (arg argument) get() string {
-    return arg.val.get()
+func (val floatValue) getRaw() interface{} {
+	return *(val.value)
 }
 
-func (arg argument) setTrue() error {
-    return arg.val.setTrue()
+func (val floatValue) clone() value {
+	return newFloatValue(val.defaultValue, val.constValue, val.checkValue)
 }
 
-func (arg argument) setFalse() error {
-    return arg.val.setFalse()
+func (val floatValue) setDefault() {
+	*(val.value) = val.defaultValue
 }
 
-func (arg argument) setConstant() error {
-    return arg.val.setConstant()
+func (val floatValue) setTrue() error {
+	return fmt.Errorf("Invalid action for float: Store True")
 }
 
-func (arg argument) increment() error{
-    return arg.val.increment()
+func (val floatValue) setFalse() error {
+	return fmt.Errorf("Invalid action for float: Store False")
 }
 
-// ==CLASS argument END==
+func (val floatValue) setConstant() error {
+	*(val.value) = val.constValue
+	return nil
+}
 
+func (val floatValue) increment() error{
+	return fmt.Errorf("Invalid action for float: Increment")
+}
+// ==CLASS floatValue END==
 
 
+// ==CLASS durationValue BEGIN==
+type DurationArgCheckFunc func(time.Duration) bool
 
-// ==CLASS argumentsGroup==
-type argumentsGroup struct {
-	name      string
-	description string
-	parser    *argParser
-	arguments []*argument
-	required  bool
-	exclusive bool
+type durationValue struct {
+	constValue   time.Duration
+	defaultValue time.Duration
+	unit         time.Duration // unit of bare numbers, 0 to reject them
+	value        *time.Duration
+	checkValue   DurationArgCheckFunc
 }
 
+func newDurationValue(defaultValue time.Duration, constValue time.Duration, unit time.Duration, checkValue DurationArgCheckFunc) durationValue{
+	val := durationValue{}
+	val.defaultValue = defaultValue
+	val.constValue = constValue
+	val.unit = unit
+	val.value = new(time.Duration)
+	val.checkValue = checkValue
 
-// CONSTRUCTORS of argumentsGroup
-func newArgumentGroup(name string, description string, required bool, exclusive bool, parser *argParser) *argumentsGroup{
-	group := new(argumentsGroup)
-
-	group.name = strings.ToLower(name)
-	group.description = description
-	group.parser = parser
-	group.arguments = make([]*argument, 0, 8)
-	group.required = required
-	group.exclusive = exclusive
+	val.setDefault()
 
-	return group
+	return val
 }
 
-
-// PUBLIC METHODS of argumentsGroup
-func (argGroup *argumentsGroup) AddInt(name string, shortcut rune, description string, mandatory bool, action int, defaultValue int, constValue int, checkValue IntArgCheckFunc) (*int, error){
-	return argGroup.parser.AddInt(name, shortcut, description, mandatory, action, defaultValue, constValue, checkValue, argGroup.name)
+func (val durationValue) get() string {
+	return (*(val.value)).String()
 }
 
-func (argGroup *argumentsGroup) AddString(name string, shortcut rune, description string, mandatory bool, action int, defaultValue string, constValue string, checkValue StringArgCheckFunc) (*string, error){
-	return argGroup.parser.AddString(name, shortcut, description, mandatory, action, defaultValue, constValue, checkValue, argGroup.name)
+func (val durationValue) getDefault() string {
+	if val.defaultValue == 0 {
+		return ""
+	}
+	return val.defaultValue.String()
 }
 
-func (argGroup *argumentsGroup) AddBool(name string, shortcut rune, description string, mandatory bool, action int, defaultValue bool, constValue bool, checkValue BoolArgCheckFunc) (*bool, error){
-	return argGroup.parser.AddBool(name, shortcut, description, mandatory, action, defaultValue, constValue, checkValue, argGroup.name)
-}
+// errDurationRange is returned by durationValue.parse for bare numbers that
+// do not fit in a time.Duration
+var errDurationRange = errors.New("is out of range")
 
-// PRIVATE METHODS of argumentsGroup
-func (argGroup *argumentsGroup) addArgument(arg *argument) {
-	argGroup.arguments = append(argGroup.arguments, arg)
+func (val durationValue) parse(value string) (time.Duration, error) {
+	if val.unit != 0 {
+		// bare numbers are expressed in the configured unit, Inf and NaN are
+		// parsed as durations and rejected by time.ParseDuration
+		if valf, err := strconv.ParseFloat(value, 64); err == nil && !math.IsInf(valf, 0) && !math.IsNaN(valf) {
+			nanoseconds := valf * float64(val.unit)
+			if nanoseconds >= float64(math.MaxInt64) || nanoseconds < float64(math.MinInt64) {
+				return 0, errDurationRange
+			}
+			return time.Duration(nanoseconds), nil
+		}
+	}
+	return time.ParseDuration(value)
 }
 
-func (argGroup *argumentsGroup) getArgument(name string) (*argument, bool){
-	if name == "" {
-		return nil, false
+func (val durationValue) set(value string, name string, shortcut rune) error {
+	vald, err := val.parse(value)
+	if errors.Is(err, errDurationRange) {
+		return invalidValue(value, name, shortcut, err.Error())
+	}
+	if err != nil {
+		return invalidValue(value, name, shortcut, "must be a duration")
 	}
 
-	lowerName := strings.ToLower(name)
-
-	for _, arg := range argGroup.arguments {
-		if lowerName == arg.name {
-			return arg, true
+	if val.checkValue != nil{
+		if !val.checkValue(vald){
+			return invalidValue(value, name, shortcut, "must meet custom restriction")
 		}
 	}
-	return nil, false
+
+	*(val.value) = vald
+	return nil
 }
 
-func (argGroup *argumentsGroup) existsArgument(name string) bool {
-	_, exists := argGroup.getArgument(name)
-	return exists
+func (val durationValue) typeName() string {
+	return "duration"
 }
 
+func (val durationValue) getChoices() []string {
+	return nil
+}
 
-func (argGroup *argumentsGroup) usage() string{
+func (val durationValue) getRaw() interface{} {
+	return *(val.value)
+}
 
-	usages := make([]string, 0, 16)
+func (val durationValue) clone() value {
+	return newDurationValue(val.defaultValue, val.constValue, val.unit, val.checkValue)
+}
 
+func (val durationValue) setDefault() {
+	*(val.value) = val.defaultValue
+}
 
-	if argGroup.exclusive {
-		for _, arg := range argGroup.arguments {
-				usages = append(usages, arg.usage())
-		}
+func (val durationValue) setTrue() error {
+	return fmt.Errorf("Invalid action for duration: Store True")
+}
 
-	
-		return fmt.Sprintf("[%s]", strings.Join(usages , " | "))
-	}
+func (val durationValue) setFalse() error {
+	return fmt.Errorf("Invalid action for duration: Store False")
+}
 
-	for _, arg := range argGroup.arguments {
-				usages = append(usages, fmt.Sprintf("[%s]", arg.usage()))
-	}
+func (val durationValue) setConstant() error {
+	*(val.value) = val.constValue
+	return nil
+}
 
-	return strings.Join(usages , " ")
+func (val durationValue) increment() error{
+	return fmt.Errorf("Invalid action for duration: Increment")
 }
+// ==CLASS durationValue END==
 
-// ==CLASS argumentsGroup END==
 
+// ==CLASS stringSliceValue BEGIN==
+type stringSliceValue struct {
+	defaultValue []string
+	value        *[]string
+	appended     *bool // false until the first value replaces the default
+	checkValue   StringArgCheckFunc
+}
 
+func newStringSliceValue(defaultValue []string, checkValue StringArgCheckFunc) stringSliceValue{
+	val := stringSliceValue{}
+	val.defaultValue = defaultValue
+	val.value = new([]string)
+	val.appended = new(bool)
+	val.checkValue = checkValue
 
+	val.setDefault()
 
+	return val
+}
 
-// ==CLASS argParser BEGIN==
-type argParser struct {
-	name       	string
-	description string
-	prefix     	rune
-	helpArgument *string
-	arguments  	[]*argument
-	posArguments []*argument
-	groups     map[string]*argumentsGroup
-	subparsers map[string]*argParser
-	subparserRequired bool
-	selectedSubparser *string
+func (val stringSliceValue) get() string {
+	return strings.Join(*(val.value), ",")
 }
 
+func (val stringSliceValue) getDefault() string {
+	return strings.Join(val.defaultValue, ",")
+}
 
-// CONSTRUCTORS of argParser
-func NewArgParser(name string, description string, includeHelp bool) (*argParser, error) {
-	parser := new(argParser)
+func (val stringSliceValue) set(value string, name string, shortcut rune) error {
 
-	if name == "" {
-		parser.name = filepath.Base(os.Args[0])
-	} else {
-		if !isValidGroupName(name) {
-			return nil, fmt.Errorf("Invalid name %s for parser, it must begin for letter and only contains letters and -", name)
+	if val.checkValue != nil{
+		if !val.checkValue(value){
+			return invalidValue(value, name, shortcut, "must meet custom restriction")
 		}
-		parser.name = strings.ToLower(name)
 	}
 
-	parser.description =  description
-	parser.arguments = []*argument{}
-	parser.posArguments = []*argument{}
-	parser.groups = map[string]*argumentsGroup{}
-	parser.subparsers = map[string]*argParser{}
-	parser.subparserRequired = false
-	parser.prefix = pARAMPREFIX
-	parser.selectedSubparser = new(string)
-
-	// adds help param
-	
-	if includeHelp {
-		aux, err := parser.AddString("--help", 'h', "Print this message", false, ActionHelp, "", "", nil, "")
-		
-		if err != nil {
-			fmt.Println(err)
-		} else {
-			parser.helpArgument = aux
-		}
+	if !*(val.appended) {
+		*(val.value) = []string{}
+		*(val.appended) = true
 	}
+	*(val.value) = append(*(val.value), value)
+	return nil
+}
 
-	return parser, nil
+func (val stringSliceValue) typeName() string {
+	return "[]string"
 }
 
+func (val stringSliceValue) getChoices() []string {
+	return nil
+}
 
-// PRIVATE METHODS of argParser
-func (parser *argParser) existsGroup(name string) bool {
-	_, exists := parser.groups[strings.ToLower(name)]
-	return exists
+func (val stringSliceValue) getRaw() interface{} {
+	return append([]string{}, *(val.value)...)
 }
 
-func (parser *argParser) existsSubparser(name string) bool {
-	_, exists := parser.subparsers[strings.ToLower(name)]
-	return exists
+func (val stringSliceValue) clone() value {
+	return newStringSliceValue(val.defaultValue, val.checkValue)
 }
 
-func (parser *argParser) existsArgument(name string) bool {
-	_, exists := parser.getArgument(name)
-	return exists
+func (val stringSliceValue) setDefault() {
+	*(val.value) = append([]string{}, val.defaultValue...)
+	*(val.appended) = false
 }
 
-func (parser *argParser) existsArgumentInGroups(name string) bool{
-	
-	if name == "" {
-		return false
-	}
+func (val stringSliceValue) setTrue() error {
+	return fmt.Errorf("Invalid action for string slice: Store True")
+}
 
-	lowerName := strings.ToLower(name)
+func (val stringSliceValue) setFalse() error {
+	return fmt.Errorf("Invalid action for string slice: Store False")
+}
 
-	for _, grp := range parser.groups {
-		if grp.existsArgument(lowerName) {
-			return true
+func (val stringSliceValue) setConstant() error {
+	return fmt.Errorf("Invalid action for string slice: Store Const")
+}
+
+func (val stringSliceValue) increment() error{
+	return fmt.Errorf("Invalid action for string slice: Increment")
+}
+// ==CLASS stringSliceValue END==
+
+
+// ==CLASS intSliceValue BEGIN==
+type intSliceValue struct {
+	defaultValue []int
+	value        *[]int
+	appended     *bool // false until the first value replaces the default
+	checkValue   IntArgCheckFunc
+}
+
+func newIntSliceValue(defaultValue []int, checkValue IntArgCheckFunc) intSliceValue{
+	val := intSliceValue{}
+	val.defaultValue = defaultValue
+	val.value = new([]int)
+	val.appended = new(bool)
+	val.checkValue = checkValue
+
+	val.setDefault()
+
+	return val
+}
+
+func (val intSliceValue) get() string {
+	return joinInts(*(val.value))
+}
+
+func (val intSliceValue) getDefault() string {
+	return joinInts(val.defaultValue)
+}
+
+func (val intSliceValue) set(value string, name string, shortcut rune) error {
+	vali, err := strconv.ParseInt(value, 0, 0)
+	if err != nil {
+		return invalidValue(value, name, shortcut, "must be an integer")
+	}
+	valInt := int(vali)
+
+	if val.checkValue != nil{
+		if !val.checkValue(valInt){
+			return invalidValue(value, name, shortcut, "must meet custom restriction")
 		}
 	}
 
-	return false
+	if !*(val.appended) {
+		*(val.value) = []int{}
+		*(val.appended) = true
+	}
+	*(val.value) = append(*(val.value), valInt)
+	return nil
 }
 
-func (parser *argParser) existsArgumentFromShortcut(shortcut rune) bool {
-	_, exists := parser.getArgumentFromShortcut(shortcut)
-	return exists
+func (val intSliceValue) typeName() string {
+	return "[]int"
 }
 
-func (parser *argParser) getArgumentCategory(argument string) int{
+func (val intSliceValue) getChoices() []string {
+	return nil
+}
 
-	var argLen = len(argument)
-	var category = ValueCategory
-	//var char rune = 0
+func (val intSliceValue) getRaw() interface{} {
+	return append([]int{}, *(val.value)...)
+}
 
-	if argLen == 0 {
-		return ValueCategory
-	}
+func (val intSliceValue) clone() value {
+	return newIntSliceValue(val.defaultValue, val.checkValue)
+}
 
+func (val intSliceValue) setDefault() {
+	*(val.value) = append([]int{}, val.defaultValue...)
+	*(val.appended) = false
+}
 
-	if argument[0] == '-'{
-		if argLen == 1 {
-			return ValueCategory
-		}
+func (val intSliceValue) setTrue() error {
+	return fmt.Errorf("Invalid action for int slice: Store True")
+}
 
-		if argument[1] == '-'{
-			if argLen == 2 {
-				return ValueCategory
-			} else {
-				//could be an argument name
-				groups := strings.Split(argument, "=")
+func (val intSliceValue) setFalse() error {
+	return fmt.Errorf("Invalid action for int slice: Store False")
+}
 
-				name := groups[0]
+func (val intSliceValue) setConstant() error {
+	return fmt.Errorf("Invalid action for int slice: Store Const")
+}
 
-				_, exists := parser.getArgument(name)
+func (val intSliceValue) increment() error{
+	return fmt.Errorf("Invalid action for int slice: Increment")
+}
+// ==CLASS intSliceValue END==
 
-				if !exists{
-					return ValueCategory
-				}
 
-				if len(groups) == 1 {
-					return NameCategory
-				}
+// ==CLASS stringMapValue BEGIN==
+type StringMapArgCheckFunc func(string, string) bool
 
-				if len(groups) > 2 {
-					return NameValueCategory
-				}
+type stringMapValue struct {
+	defaultValue     map[string]string
+	value            *map[string]string
+	appended         *bool // false until the first pair replaces the default
+	separator        string
+	rejectDuplicates bool
+	checkValue       StringMapArgCheckFunc
+}
 
-				if len(groups[1]) == 0 {
-					return NameEqCategory
-				} else {
-					return NameValueCategory
-				}
-				
-			}
-		} else {
-			// could be a Shortcut
-			_, exists := parser.getArgumentFromShortcut(rune(argument[1]))
-			
-			if !exists {
-				return ValueCategory
-			} else {
-				category = ShortcutCategory
-			}
+func newStringMapValue(defaultValue map[string]string, separator string, rejectDuplicates bool, checkValue StringMapArgCheckFunc) stringMapValue{
+	val := stringMapValue{}
+	val.defaultValue = defaultValue
+	val.value = new(map[string]string)
+	val.appended = new(bool)
+	val.separator = separator
+	val.rejectDuplicates = rejectDuplicates
+	val.checkValue = checkValue
 
-			for i := 2; i < len(argument); i++{
-				_, exists = parser.getArgumentFromShortcut(rune(argument[i]))
+	val.setDefault()
 
-				if !exists {
-					if argument[i] == '='{
-						if category == ShortcutCategory {
-							if i == len(argument) - 1{
-								return ShortcutEqCategory
-							} else {
-								return ShortcutValueCategory
-							}
-						} else if category == ShortcutGroupCategory {
-							if i == len(argument) - 1{
-								return ShortcutGroupEqCategory
-							} else {
-								return ShortcutGroupValueCategory
-							}
-						} else {
-							fmt.Printf("Oh oh, you shouldn't see this (Group category fail)\n")
-						}
-					} else {
-						return ValueCategory
-					}
-				}
+	return val
+}
 
-				category = ShortcutGroupCategory	
-			}
+func (val stringMapValue) get() string {
+	return joinStringMap(*(val.value), val.separator)
+}
 
-			return category
+func (val stringMapValue) getDefault() string {
+	return joinStringMap(val.defaultValue, val.separator)
+}
+
+func (val stringMapValue) set(value string, name string, shortcut rune) error {
+	pairs, err := splitPairs(value, val.separator)
+	if err != nil {
+		return invalidValue(value, name, shortcut, err.Error())
+	}
+
+	// the pairs are validated before the default map is replaced, so it is
+	// kept if they are invalid
+	seen := map[string]bool{}
+	for _, pair := range pairs {
+		if val.checkValue != nil {
+			if !val.checkValue(pair[0], pair[1]) {
+				return invalidValue(value, name, shortcut, "must meet custom restriction")
+			}
 		}
+		_, exists := (*(val.value))[pair[0]]
+		if ((exists && *(val.appended)) || seen[pair[0]]) && val.rejectDuplicates {
+			return invalidValue(value, name, shortcut, fmt.Sprintf("key %s is repeated", pair[0]))
+		}
+		seen[pair[0]] = true
+	}
 
+	if !*(val.appended) {
+		*(val.value) = map[string]string{}
+		*(val.appended) = true
 	}
 
-	return category
+	for _, pair := range pairs {
+		(*(val.value))[pair[0]] = pair[1]
+	}
+	return nil
 }
 
-func (parser *argParser) getArgumentFromShortcut(shortcut rune) (*argument, bool) {
+func (val stringMapValue) typeName() string {
+	return "map[string]string"
+}
 
-	if shortcut == NOSHORTCUT {
-		return nil, false
+func (val stringMapValue) getChoices() []string {
+	return nil
+}
+
+func (val stringMapValue) getRaw() interface{} {
+	values := map[string]string{}
+	for k, v := range *(val.value) {
+		values[k] = v
 	}
+	return values
+}
 
-	for _, arg := range parser.arguments {
-		if shortcut == arg.shortcut {
-			return arg, true
-		}
+func (val stringMapValue) clone() value {
+	return newStringMapValue(val.defaultValue, val.separator, val.rejectDuplicates, val.checkValue)
+}
+
+func (val stringMapValue) setDefault() {
+	*(val.value) = map[string]string{}
+	for k, v := range val.defaultValue {
+		(*(val.value))[k] = v
 	}
-	return nil, false
+	*(val.appended) = false
 }
 
-func (parser *argParser) getArgument(name string) (*argument, bool){
-	if name == "" {
-		return nil, false
+func (val stringMapValue) setTrue() error {
+	return fmt.Errorf("Invalid action for string map: Store True")
+}
+
+func (val stringMapValue) setFalse() error {
+	return fmt.Errorf("Invalid action for string map: Store False")
+}
+
+func (val stringMapValue) setConstant() error {
+	return fmt.Errorf("Invalid action for string map: Store Const")
+}
+
+func (val stringMapValue) increment() error{
+	return fmt.Errorf("Invalid action for string map: Increment")
+}
+// ==CLASS stringMapValue END==
+
+
+// ==CLASS intMapValue BEGIN==
+type IntMapArgCheckFunc func(string, int) bool
+
+type intMapValue struct {
+	defaultValue     map[string]int
+	value            *map[string]int
+	appended         *bool // false until the first pair replaces the default
+	separator        string
+	rejectDuplicates bool
+	checkValue       IntMapArgCheckFunc
+}
+
+func newIntMapValue(defaultValue map[string]int, separator string, rejectDuplicates bool, checkValue IntMapArgCheckFunc) intMapValue{
+	val := intMapValue{}
+	val.defaultValue = defaultValue
+	val.value = new(map[string]int)
+	val.appended = new(bool)
+	val.separator = separator
+	val.rejectDuplicates = rejectDuplicates
+	val.checkValue = checkValue
+
+	val.setDefault()
+
+	return val
+}
+
+func (val intMapValue) get() string {
+	return joinIntMap(*(val.value), val.separator)
+}
+
+func (val intMapValue) getDefault() string {
+	return joinIntMap(val.defaultValue, val.separator)
+}
+
+func (val intMapValue) set(value string, name string, shortcut rune) error {
+	pairs, err := splitPairs(value, val.separator)
+	if err != nil {
+		return invalidValue(value, name, shortcut, err.Error())
 	}
 
-	lowerName := strings.ToLower(name)
+	// the pairs are validated before the default map is replaced, so it is
+	// kept if they are invalid
+	seen := map[string]bool{}
+	ints := make([]int, 0, len(pairs))
+	for _, pair := range pairs {
+		vali, err := strconv.ParseInt(pair[1], 0, 0)
+		if err != nil {
+			return invalidValue(value, name, shortcut, fmt.Sprintf("value of key %s must be an integer", pair[0]))
+		}
+		valInt := int(vali)
 
-	for _, arg := range parser.arguments {
-		if lowerName == arg.name {
-			return arg, true
+		if val.checkValue != nil {
+			if !val.checkValue(pair[0], valInt) {
+				return invalidValue(value, name, shortcut, "must meet custom restriction")
+			}
+		}
+		_, exists := (*(val.value))[pair[0]]
+		if ((exists && *(val.appended)) || seen[pair[0]]) && val.rejectDuplicates {
+			return invalidValue(value, name, shortcut, fmt.Sprintf("key %s is repeated", pair[0]))
 		}
+		seen[pair[0]] = true
+		ints = append(ints, valInt)
 	}
 
-	//arg, ok := parser.arguments[strings.ToLower(name)]
-	return nil, false
+	if !*(val.appended) {
+		*(val.value) = map[string]int{}
+		*(val.appended) = true
+	}
+
+	for i, pair := range pairs {
+		(*(val.value))[pair[0]] = ints[i]
+	}
+	return nil
 }
 
-func (parser *argParser) getGroup(name string) (*argumentsGroup, bool){
-	group, ok := parser.groups[strings.ToLower(name)]
-	return group, ok
+func (val intMapValue) typeName() string {
+	return "map[string]int"
 }
 
-func (parser *argParser) getSubparser(name string) (*argParser, bool){
-	subparser, ok := parser.subparsers[strings.ToLower(name)]
-	return subparser, ok
+func (val intMapValue) getChoices() []string {
+	return nil
 }
 
-func (parser *argParser) setDefaultValues() {
+func (val intMapValue) getRaw() interface{} {
+	values := map[string]int{}
+	for k, v := range *(val.value) {
+		values[k] = v
+	}
+	return values
+}
 
-	for _, arg := range parser.arguments {
-		arg.setDefault()
+func (val intMapValue) clone() value {
+	return newIntMapValue(val.defaultValue, val.separator, val.rejectDuplicates, val.checkValue)
+}
+
+func (val intMapValue) setDefault() {
+	*(val.value) = map[string]int{}
+	for k, v := range val.defaultValue {
+		(*(val.value))[k] = v
 	}
+	*(val.appended) = false
+}
 
+func (val intMapValue) setTrue() error {
+	return fmt.Errorf("Invalid action for int map: Store True")
 }
 
-func (parser *argParser) isValidFlagName(name string) bool {
+func (val intMapValue) setFalse() error {
+	return fmt.Errorf("Invalid action for int map: Store False")
+}
 
-	//empty name is correct
-	if len(name) == 0 {
-		return true
-	}
+func (val intMapValue) setConstant() error {
+	return fmt.Errorf("Invalid action for int map: Store Const")
+}
 
-	if len(name) < 3 {
-		//name must start with letter
-		if !isLetter(rune(name[0])){
-			return false
-		}
-	} else {
-		//name must start with letter or -- 
-		posBegin := rune(name[0]) == parser.prefix && rune(name[1]) == parser.prefix && isLetter(rune(name[2]))
+func (val intMapValue) increment() error{
+	return fmt.Errorf("Invalid action for int map: Increment")
+}
+// ==CLASS intMapValue END==
+
+
+// ==CLASS Argument BEGIN==
+type Argument struct{
+    name         string
+    shortcut     rune
+    description  string
+    action       int
+    mandatory    bool
+    positional   bool
+    val          value
+    parser       *ArgParser
+    env          string // environment variable used if the flag is absent
+    completion   CompletionFunc // candidates for the value, see Complete
+}
+
+// CONSTRUCTORS of Argument
+func  newArgument(name string, shortcut rune, description string, mandatory bool) *Argument {
+	
+	var arg = new(Argument)
+
+	arg.name = strings.ToLower(name)
+	arg.shortcut = shortcut
+	arg.description = description
+	arg.action = ActionStoreValue
+	arg.mandatory = mandatory
+	arg.positional = isPositionalName(name)
+
+	return arg
+}
+
+
+// PUBLIC METHODS OF arguments
+
+// Name returns the name of the argument, Ex: --zeta, or empty if it only
+// has a shortcut
+func (arg Argument) Name() string {
+	return arg.name
+}
+
+// Shortcut returns the shortcut of the argument or NOSHORTCUT
+func (arg Argument) Shortcut() rune {
+	return arg.shortcut
+}
+
+func (arg Argument) Description() string {
+	return arg.description
+}
+
+// Action returns the action of the argument, Ex: ActionStoreValue
+func (arg Argument) Action() int {
+	return arg.action
+}
+
+func (arg Argument) Mandatory() bool {
+	return arg.mandatory
+}
+
+func (arg Argument) Positional() bool {
+	return arg.positional
+}
+
+// Default returns the default value as it is shown in Help, it is empty if
+// the default is the zero value
+func (arg Argument) Default() string {
+	return arg.val.getDefault()
+}
+
+// Env returns the environment variable used when the argument is not
+// given, empty if there is none
+func (arg Argument) Env() string {
+	return arg.envName()
+}
+
+// Choices returns the allowed values of the argument, nil if any value is
+// allowed
+func (arg Argument) Choices() []string {
+	return arg.val.getChoices()
+}
+
+// Type returns the name of the type of the value, Ex: int, []string or
+// duration
+func (arg Argument) Type() string {
+	return arg.val.typeName()
+}
+
+func (arg Argument) takesValue() bool {
+	return arg.action == ActionStoreValue || arg.action == ActionAppend
+}
+
+// metavar returns the placeholder shown for the value in usage messages
+func (arg Argument) metavar() string {
+	if choices := arg.val.getChoices(); len(choices) > 0 {
+		return fmt.Sprintf("{%s}", strings.Join(choices, ","))
+	}
+	if arg.positional {
+		return arg.name
+	}
+	if arg.name != "" {
+		return strings.ToUpper(arg.name[2:])
+	}
+	return strings.ToUpper(string(arg.shortcut))
+}
+
+func (arg Argument) usage() string {
+
+	if arg.positional {
+		return arg.metavar()
+	}
+
+	if arg.name != ""{
+		if arg.shortcut != NOSHORTCUT {
+			if arg.takesValue() {
+				return fmt.Sprintf("%s/-%c %s", arg.name, arg.shortcut, arg.metavar())
+			} else {
+				return fmt.Sprintf("%s/-%c", arg.name, arg.shortcut)
+			}
+		} else {
+			if arg.takesValue() {
+				return fmt.Sprintf("%s %s", arg.name, arg.metavar())
+			} else {
+				return fmt.Sprintf("%s", arg.name)
+			}
+		}
+	} else {
+		if arg.takesValue() {
+			return fmt.Sprintf("-%c %s", arg.shortcut, arg.metavar())
+		} else {
+			return fmt.Sprintf("-%c", arg.shortcut)
+		}
+	}
+}
+
+func (arg Argument) help() string{
+	return fmt.Sprintf("%s\t%s", arg.usage(), arg.helpDescription())
+}
+
+// helpDescription returns the description followed by the default value
+// and the environment variable, if any
+func (arg Argument) helpDescription() string {
+	description := arg.description
+	if arg.takesValue() {
+		if def := arg.val.getDefault(); def != "" {
+			description += fmt.Sprintf(" (default: %s)", def)
+		}
+	}
+	if env := arg.envName(); env != "" {
+		description += fmt.Sprintf(" [env: %s]", env)
+	}
+	return description
+}
+
+// envName returns the environment variable of the argument, the one set
+// with SetEnv or the one derived from the env prefix of the parser
+func (arg Argument) envName() string {
+	if arg.positional || arg.action == ActionHelp {
+		return ""
+	}
+	if arg.env != "" {
+		return arg.env
+	}
+	if arg.parser == nil || arg.parser.envPrefix == "" || arg.name == "" {
+		return ""
+	}
+	return arg.parser.envPrefix + "_" + strings.ToUpper(strings.ReplaceAll(strings.TrimLeft(arg.name, "-"), "-", "_"))
+}
+
+// setExternal sets the value of the argument from an environment variable
+// or config file, flags that do not take a value are given if value is true
+func (arg Argument) setExternal(value string) error {
+	switch arg.action {
+		case ActionStoreValue, ActionAppend, ActionIncrement:
+			return arg.set(value)
+	}
+
+	given, err := strconv.ParseBool(value)
+	if err != nil {
+		return invalidValue(value, arg.name, arg.shortcut, "must be a boolean")
+	}
+	if !given {
+		return nil
+	}
+
+	switch arg.action {
+		case ActionStoreTrue:
+			return arg.setTrue()
+		case ActionStoreFalse:
+			return arg.setFalse()
+		case ActionStoreConst:
+			return arg.setConstant()
+	}
+	return nil
+}
+
+func (arg Argument) set(value string) error {
+    err := arg.val.set(value, arg.name, arg.shortcut)
+
+    var invalid *InvalidValueError
+    if errors.As(err, &invalid) {
+        invalid.Parser = arg.parser.path()
+    }
+    return err
+}
+
+// commandLineSource returns the source of the value when the argument is
+// given in the command line
+func (arg Argument) commandLineSource() Source {
+	if arg.action == ActionStoreConst {
+		return SourceConst
+	}
+	return SourceCommandLine
+}
+
+func (arg Argument) setDefault() {
+	arg.val.setDefault()
+}
+
+func (arg Argument) get() string {
+    return arg.val.get()
+}
+
+func (arg Argument) setTrue() error {
+    return arg.val.setTrue()
+}
+
+func (arg Argument) setFalse() error {
+    return arg.val.setFalse()
+}
+
+func (arg Argument) setConstant() error {
+    return arg.val.setConstant()
+}
+
+func (arg Argument) increment() error{
+    return arg.val.increment()
+}
+
+// ==CLASS Argument END==
+
+
+
+
+// ==CLASS ArgumentsGroup==
+type ArgumentsGroup struct {
+	name      string
+	description string
+	parser    *ArgParser
+	arguments []*Argument
+	required  bool
+	exclusive bool
+}
+
+
+// CONSTRUCTORS of ArgumentsGroup
+func newArgumentGroup(name string, description string, required bool, exclusive bool, parser *ArgParser) *ArgumentsGroup{
+	group := new(ArgumentsGroup)
+
+	group.name = strings.ToLower(name)
+	group.description = description
+	group.parser = parser
+	group.arguments = make([]*Argument, 0, 8)
+	group.required = required
+	group.exclusive = exclusive
+
+	return group
+}
+
+
+// PUBLIC METHODS of ArgumentsGroup
+func (argGroup *ArgumentsGroup) AddInt(name string, shortcut rune, description string, mandatory bool, action int, defaultValue int, constValue int, checkValue IntArgCheckFunc) (*int, error){
+	return argGroup.parser.AddInt(name, shortcut, description, mandatory, action, defaultValue, constValue, checkValue, argGroup.name)
+}
+
+func (argGroup *ArgumentsGroup) AddString(name string, shortcut rune, description string, mandatory bool, action int, defaultValue string, constValue string, checkValue StringArgCheckFunc) (*string, error){
+	return argGroup.parser.AddString(name, shortcut, description, mandatory, action, defaultValue, constValue, checkValue, argGroup.name)
+}
+
+func (argGroup *ArgumentsGroup) AddBool(name string, shortcut rune, description string, mandatory bool, action int, defaultValue bool, constValue bool, checkValue BoolArgCheckFunc) (*bool, error){
+	return argGroup.parser.AddBool(name, shortcut, description, mandatory, action, defaultValue, constValue, checkValue, argGroup.name)
+}
+
+func (argGroup *ArgumentsGroup) AddIntChoices(name string, shortcut rune, description string, mandatory bool, action int, defaultValue int, constValue int, choices []int, checkValue IntArgCheckFunc) (*int, error){
+	return argGroup.parser.AddIntChoices(name, shortcut, description, mandatory, action, defaultValue, constValue, choices, checkValue, argGroup.name)
+}
+
+func (argGroup *ArgumentsGroup) AddStringChoices(name string, shortcut rune, description string, mandatory bool, action int, defaultValue string, constValue string, choices []string, checkValue StringArgCheckFunc) (*string, error){
+	return argGroup.parser.AddStringChoices(name, shortcut, description, mandatory, action, defaultValue, constValue, choices, checkValue, argGroup.name)
+}
+
+func (argGroup *ArgumentsGroup) AddFloat(name string, shortcut rune, description string, mandatory bool, action int, defaultValue float64, constValue float64, checkValue FloatArgCheckFunc) (*float64, error){
+	return argGroup.parser.AddFloat(name, shortcut, description, mandatory, action, defaultValue, constValue, checkValue, argGroup.name)
+}
+
+func (argGroup *ArgumentsGroup) AddDuration(name string, shortcut rune, description string, mandatory bool, action int, defaultValue time.Duration, constValue time.Duration, unit time.Duration, checkValue DurationArgCheckFunc) (*time.Duration, error){
+	return argGroup.parser.AddDuration(name, shortcut, description, mandatory, action, defaultValue, constValue, unit, checkValue, argGroup.name)
+}
+
+func (argGroup *ArgumentsGroup) AddStringSlice(name string, shortcut rune, description string, mandatory bool, defaultValue []string, checkValue StringArgCheckFunc) (*[]string, error){
+	return argGroup.parser.AddStringSlice(name, shortcut, description, mandatory, defaultValue, checkValue, argGroup.name)
+}
+
+func (argGroup *ArgumentsGroup) AddIntSlice(name string, shortcut rune, description string, mandatory bool, defaultValue []int, checkValue IntArgCheckFunc) (*[]int, error){
+	return argGroup.parser.AddIntSlice(name, shortcut, description, mandatory, defaultValue, checkValue, argGroup.name)
+}
+
+func (argGroup *ArgumentsGroup) AddStringMap(name string, shortcut rune, description string, mandatory bool, separator string, rejectDuplicates bool, defaultValue map[string]string, checkValue StringMapArgCheckFunc) (*map[string]string, error){
+	return argGroup.parser.AddStringMap(name, shortcut, description, mandatory, separator, rejectDuplicates, defaultValue, checkValue, argGroup.name)
+}
+
+func (argGroup *ArgumentsGroup) AddIntMap(name string, shortcut rune, description string, mandatory bool, separator string, rejectDuplicates bool, defaultValue map[string]int, checkValue IntMapArgCheckFunc) (*map[string]int, error){
+	return argGroup.parser.AddIntMap(name, shortcut, description, mandatory, separator, rejectDuplicates, defaultValue, checkValue, argGroup.name)
+}
+
+func (argGroup *ArgumentsGroup) Name() string {
+	return argGroup.name
+}
+
+func (argGroup *ArgumentsGroup) Description() string {
+	return argGroup.description
+}
+
+// Required reports if at least one argument of the group must be given
+func (argGroup *ArgumentsGroup) Required() bool {
+	return argGroup.required
+}
+
+// Exclusive reports if at most one argument of the group can be given
+func (argGroup *ArgumentsGroup) Exclusive() bool {
+	return argGroup.exclusive
+}
+
+// constraint describes the requirements of the group for the docs
+func (argGroup *ArgumentsGroup) constraint() string {
+	switch {
+		case argGroup.required && argGroup.exclusive:
+			return "Exactly one of these arguments must be given."
+		case argGroup.required:
+			return "At least one of these arguments must be given."
+		case argGroup.exclusive:
+			return "At most one of these arguments can be given."
+	}
+	return ""
+}
+
+// Arguments returns the arguments of the group in definition order
+func (argGroup *ArgumentsGroup) Arguments() []*Argument {
+	return append([]*Argument{}, argGroup.arguments...)
+}
+
+// PRIVATE METHODS of ArgumentsGroup
+func (argGroup *ArgumentsGroup) addArgument(arg *Argument) {
+	argGroup.arguments = append(argGroup.arguments, arg)
+}
+
+func (argGroup *ArgumentsGroup) getArgument(name string) (*Argument, bool){
+	if name == "" {
+		return nil, false
+	}
+
+	lowerName := strings.ToLower(name)
+
+	for _, arg := range argGroup.arguments {
+		if lowerName == arg.name {
+			return arg, true
+		}
+	}
+	return nil, false
+}
+
+func (argGroup *ArgumentsGroup) existsArgument(name string) bool {
+	_, exists := argGroup.getArgument(name)
+	return exists
+}
+
+
+func (argGroup *ArgumentsGroup) usage() string{
+
+	usages := make([]string, 0, 16)
+
+
+	if argGroup.exclusive {
+		for _, arg := range argGroup.arguments {
+				usages = append(usages, arg.usage())
+		}
+
+	
+		return fmt.Sprintf("[%s]", strings.Join(usages , " | "))
+	}
+
+	for _, arg := range argGroup.arguments {
+				usages = append(usages, fmt.Sprintf("[%s]", arg.usage()))
+	}
+
+	return strings.Join(usages , " ")
+}
+
+// ==CLASS ArgumentsGroup END==
+
+
+
+
+
+// ==CLASS ArgParser BEGIN==
+type ArgParser struct {
+	name       	string
+	description string
+	prefix     	rune
+	helpArgument *string
+	arguments  	[]*Argument
+	posArguments []*Argument
+	groups     map[string]*ArgumentsGroup
+	subparsers map[string]*ArgParser
+	parent     *ArgParser
+	subparserRequired bool
+	selectedSubparser *string
+	allowUnknown bool
+	suggestionDistance int
+	remainder []string
+	bindings []func() // run after a successful Parse, Ex: to fill a struct
+	envPrefix string
+	configFile string
+	configArgument *Argument
+	config *configSection // loaded in the last Parse
+	sources map[*Argument]Source // arguments set in the last Parse
+	parentConfig *configSection // section given by the parent parser
+	fromfilePrefixChars string
+	fromfileOnePerLine bool
+	completionCommand string // called by the completion scripts, "" if disabled
+}
+
+
+// CONSTRUCTORS of ArgParser
+func NewArgParser(name string, description string, includeHelp bool) (*ArgParser, error) {
+	parser := new(ArgParser)
+
+	if name == "" {
+		parser.name = filepath.Base(os.Args[0])
+	} else {
+		if !isValidGroupName(name) {
+			return nil, fmt.Errorf("Invalid name %s for parser, it must begin for letter and only contains letters and -", name)
+		}
+		parser.name = strings.ToLower(name)
+	}
+
+	parser.description =  description
+	parser.arguments = []*Argument{}
+	parser.posArguments = []*Argument{}
+	parser.groups = map[string]*ArgumentsGroup{}
+	parser.subparsers = map[string]*ArgParser{}
+	parser.subparserRequired = false
+	parser.prefix = pARAMPREFIX
+	parser.selectedSubparser = new(string)
+	parser.suggestionDistance = 2
+
+	// adds help param
+	
+	if includeHelp {
+		aux, err := parser.AddString("--help", 'h', "Print this message", false, ActionHelp, "", "", nil, "")
+		
+		if err != nil {
+			fmt.Println(err)
+		} else {
+			parser.helpArgument = aux
+		}
+	}
+
+	return parser, nil
+}
+
+
+// PRIVATE METHODS of ArgParser
+func (parser *ArgParser) existsGroup(name string) bool {
+	_, exists := parser.groups[strings.ToLower(name)]
+	return exists
+}
+
+func (parser *ArgParser) existsSubparser(name string) bool {
+	_, exists := parser.subparsers[strings.ToLower(name)]
+	return exists
+}
+
+func (parser *ArgParser) existsArgument(name string) bool {
+	_, exists := parser.getArgument(name)
+	return exists
+}
+
+func (parser *ArgParser) existsArgumentInGroups(name string) bool{
+	
+	if name == "" {
+		return false
+	}
+
+	lowerName := strings.ToLower(name)
+
+	for _, grp := range parser.groups {
+		if grp.existsArgument(lowerName) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (parser *ArgParser) existsArgumentFromShortcut(shortcut rune) bool {
+	_, exists := parser.getArgumentFromShortcut(shortcut)
+	return exists
+}
+
+func (parser *ArgParser) getArgumentCategory(argument string) int{
+
+	var argLen = len(argument)
+	var category = ValueCategory
+	//var char rune = 0
+
+	if argLen == 0 {
+		return ValueCategory
+	}
+
+
+	if argument[0] == '-'{
+		if argLen == 1 {
+			return ValueCategory
+		}
+
+		// shortcuts can only be letters, so negative numbers are values
+		if isNegativeNumber(argument) {
+			return ValueCategory
+		}
+
+		if argument[1] == '-'{
+			if argLen == 2 {
+				return TerminatorCategory
+			} else {
+				//could be an argument name
+				groups := strings.Split(argument, "=")
+
+				name := groups[0]
+
+				_, exists := parser.getArgument(name)
+
+				if !exists{
+					return parser.unknownCategory()
+				}
+
+				if len(groups) == 1 {
+					return NameCategory
+				}
+
+				if len(groups) > 2 {
+					return NameValueCategory
+				}
+
+				if len(groups[1]) == 0 {
+					return NameEqCategory
+				} else {
+					return NameValueCategory
+				}
+				
+			}
+		} else {
+			// could be a Shortcut
+			_, exists := parser.getArgumentFromShortcut(rune(argument[1]))
+			
+			if !exists {
+				return parser.unknownCategory()
+			} else {
+				category = ShortcutCategory
+			}
+
+			for i := 2; i < len(argument); i++{
+				// the rest is the value of the previous shortcut (Ex: -zVALUE)
+				prevArg, _ := parser.getArgumentFromShortcut(rune(argument[i-1]))
+				if prevArg.takesValue() && argument[i] != '=' {
+					return ShortcutGroupCategory
+				}
+
+				_, exists = parser.getArgumentFromShortcut(rune(argument[i]))
+
+				if !exists {
+					if argument[i] == '='{
+						if category == ShortcutCategory {
+							if i == len(argument) - 1{
+								return ShortcutEqCategory
+							} else {
+								return ShortcutValueCategory
+							}
+						} else if category == ShortcutGroupCategory {
+							if i == len(argument) - 1{
+								return ShortcutGroupEqCategory
+							} else {
+								return ShortcutGroupValueCategory
+							}
+						} else {
+							fmt.Printf("Oh oh, you shouldn't see this (Group category fail)\n")
+						}
+					} else {
+						return parser.unknownCategory()
+					}
+				}
+
+				category = ShortcutGroupCategory	
+			}
+
+			return category
+		}
+
+	}
+
+	return category
+}
+
+// unknownCategory is the category of flags that are not defined in the
+// parser, they are treated as values if unknown arguments are allowed
+func (parser *ArgParser) unknownCategory() int {
+	if parser.allowUnknown {
+		return ValueCategory
+	}
+	return UnknownCategory
+}
+
+// path returns the names of the parser and its parents, Ex: tool remote add
+func (parser *ArgParser) path() string {
+	if parser.parent == nil {
+		return parser.name
+	}
+	return parser.parent.path() + " " + parser.name
+}
+
+func (parser *ArgParser) subparserNames() []string {
+	names := make([]string, 0, len(parser.subparsers))
+	for _, subparser := range parser.subparsers {
+		names = append(names, subparser.name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (parser *ArgParser) missingValue(arg *Argument) error {
+	return &MissingValueError{Argument: arg.name, Shortcut: arg.shortcut, Parser: parser.path()}
+}
+
+func (parser *ArgParser) unrecognizedArgument(argStr string) error {
+	names := make([]string, 0, len(parser.arguments))
+	for _, arg := range parser.arguments {
+		if arg.name != "" && !arg.positional {
+			names = append(names, arg.name)
+		}
+	}
+
+	name := strings.Split(argStr, "=")[0]
+	if !strings.HasPrefix(name, "--") {
+		name = "-" + name
+	}
+
+	return &UnrecognizedArgumentError{
+		Argument: argStr,
+		Parser: parser.path(),
+		Suggestions: closestNames(name, names, parser.suggestionDistance),
+	}
+}
+
+func (parser *ArgParser) unknownSubcommand(name string) error {
+	return &UnknownSubcommandError{
+		Subcommand: name,
+		Parser: parser.path(),
+		Suggestions: closestNames(strings.ToLower(name), parser.subparserNames(), parser.suggestionDistance),
+	}
+}
+
+func (parser *ArgParser) getArgumentFromShortcut(shortcut rune) (*Argument, bool) {
+
+	if shortcut == NOSHORTCUT {
+		return nil, false
+	}
+
+	for _, arg := range parser.arguments {
+		if shortcut == arg.shortcut {
+			return arg, true
+		}
+	}
+	return nil, false
+}
+
+func (parser *ArgParser) getArgument(name string) (*Argument, bool){
+	if name == "" {
+		return nil, false
+	}
+
+	lowerName := strings.ToLower(name)
+
+	for _, arg := range parser.arguments {
+		if lowerName == arg.name {
+			return arg, true
+		}
+	}
+
+	//arg, ok := parser.arguments[strings.ToLower(name)]
+	return nil, false
+}
+
+func (parser *ArgParser) getGroup(name string) (*ArgumentsGroup, bool){
+	group, ok := parser.groups[strings.ToLower(name)]
+	return group, ok
+}
+
+func (parser *ArgParser) getSubparser(name string) (*ArgParser, bool){
+	subparser, ok := parser.subparsers[strings.ToLower(name)]
+	return subparser, ok
+}
+
+// expandFromfiles replaces the arguments that begin with a fromfile prefix
+// by the arguments read from the file, recursively. files are the files
+// being expanded, to detect cycles. Arguments after -- are not expanded.
+func (parser *ArgParser) expandFromfiles(arguments []string, files []string) ([]string, error) {
+	expanded := make([]string, 0, len(arguments))
+
+	for i, argStr := range arguments {
+		if argStr == "--" {
+			return append(expanded, arguments[i:]...), nil
+		}
+
+		if len(argStr) < 2 || !strings.ContainsRune(parser.fromfilePrefixChars, rune(argStr[0])) {
+			expanded = append(expanded, argStr)
+			continue
+		}
+
+		path, err := filepath.Abs(argStr[1:])
+		if err != nil {
+			return nil, fmt.Errorf("argument file %s: %w", argStr[1:], err)
+		}
+
+		for _, file := range files {
+			if file == path {
+				return nil, fmt.Errorf("argument file %s includes itself", argStr[1:])
+			}
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("argument file %s: %w", argStr[1:], err)
+		}
+
+		fileArgs := make([]string, 0, 16)
+		for _, line := range strings.Split(string(content), "\n") {
+			line = strings.TrimRight(line, "\r")
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+
+			if parser.fromfileOnePerLine {
+				fileArgs = append(fileArgs, line)
+			} else {
+				fileArgs = append(fileArgs, StringToArgv(line)...)
+			}
+		}
+
+		fileArgs, err = parser.expandFromfiles(fileArgs, append(files, path))
+		if err != nil {
+			return nil, err
+		}
+
+		expanded = append(expanded, fileArgs...)
+	}
+
+	return expanded, nil
+}
+
+func (parser *ArgParser) getArgumentGroup(arg *Argument) (*ArgumentsGroup, bool) {
+	for _, grp := range parser.groups {
+		for _, grpArg := range grp.arguments {
+			if grpArg == arg {
+				return grp, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// setFromEnvironment sets the arguments that were not given from their
+// environment variables, except if another argument of their exclusive
+// group was given in the command line. Two arguments of an exclusive group
+// set from the environment are reported as an ExclusiveGroupError by Parse.
+func (parser *ArgParser) setFromEnvironment(argsSet map[*Argument]Source) error {
+
+	EnvLoop: for _, arg := range parser.arguments {
+		env := arg.envName()
+		if env == "" {
+			continue
+		}
+
+		if _, wasSet := argsSet[arg]; wasSet {
+			continue
+		}
+
+		value, exists := os.LookupEnv(env)
+		if !exists {
+			continue
+		}
+
+		if grp, ok := parser.getArgumentGroup(arg); ok && grp.exclusive {
+			for _, grpArg := range grp.arguments {
+				if source, wasSet := argsSet[grpArg]; wasSet && source != SourceEnvironment {
+					continue EnvLoop
+				}
+			}
+		}
+
+		err := arg.setExternal(value)
+		if err != nil {
+			return fmt.Errorf("environment variable %s: %w", env, err)
+		}
+
+		// false does not give a flag, so it does not count in its group
+		if !arg.takesValue() && arg.action != ActionIncrement {
+			if given, _ := strconv.ParseBool(value); !given {
+				continue
+			}
+		}
+		argsSet[arg] = SourceEnvironment
+	}
+
+	return nil
+}
+
+func (parser *ArgParser) setDefaultValues() {
+
+	for _, arg := range parser.arguments {
+		arg.setDefault()
+	}
+
+}
+
+func (parser *ArgParser) isValidFlagName(name string) bool {
+
+	//empty name is correct
+	if len(name) == 0 {
+		return true
+	}
+
+	if len(name) < 3 {
+		//name must start with letter
+		if !isLetter(rune(name[0])){
+			return false
+		}
+	} else {
+		//name must start with letter or -- 
+		posBegin := rune(name[0]) == parser.prefix && rune(name[1]) == parser.prefix && isLetter(rune(name[2]))
+
+		opBegin := isLetter(rune(name[0]))
+
+		if !posBegin && !opBegin{
+			return false
+		} 
+
+	}
+
+	//only letters and - are available for flags names
+	for _, char := range name {
+		if !isLetter(char) && char != '-'{
+			return false
+		}
+	}
+
+	return true
+}
+
+func (parser *ArgParser) createArg(name string, shortcut rune, description string, mandatory bool) (*Argument, error) {
+
+	var arg = newArgument(name, shortcut, description, mandatory)
+
+	if name == "" && shortcut == NOSHORTCUT {
+		return nil, fmt.Errorf("Argument must have a name and/or shortcut")
+	}
+
+	if !parser.isValidFlagName(arg.name) {
+		return nil, fmt.Errorf("Argument name only can be letters and -")
+	}
+
+	if parser.existsArgument(arg.name) {
+		return nil, fmt.Errorf("Argument %s is already defined in parser %s", name, parser.name)
+	}
+
+
+	if arg.positional {
+		arg.mandatory = true
+		arg.shortcut = NOSHORTCUT
+
+	} else {
+		// check shortcut
+		if shortcut == NOSHORTCUT {
+			arg.shortcut = NOSHORTCUT
+		} else {
+			if !isValidShortcut(shortcut) {
+				return nil, fmt.Errorf("Invalid shorcut, must be a letter")
+			}
+
+			argEx, exists := parser.getArgumentFromShortcut(shortcut)
+			if exists {
+				return nil, fmt.Errorf("Shortcut %c (arg %s) is already used in argument %s (parser %s)", shortcut, name, argEx.name, parser.name)
+			}
+		}
+
+	}
+
+	return arg, nil
+}
+
+func (parser *ArgParser) addArg(arg *Argument, group string) error {
+	if group != "" {
+		if !parser.existsGroup(group) {
+			return fmt.Errorf("Group %s is not defined", group)
+		}
+		if arg.positional {
+			return fmt.Errorf("Positional argument %s cannot belong to a group", arg.name)
+		}
+		parser.groups[group].addArgument(arg)
+	}
+
+	arg.parser = parser
+	parser.arguments = append(parser.arguments, arg)
+	//parser.arguments[arg.name] = arg
+
+	if arg.positional {
+		parser.posArguments = append(parser.posArguments, arg)
+	}
+
+	return nil
+}
+
+// PUBLIC METHODS OF ArgParser
+
+func (parser *ArgParser) SetFlagPrefix(prefix rune) {
+	parser.prefix = prefix
+}
+
+func (parser *ArgParser) SetSubparserRequired(required bool){
+	parser.subparserRequired = required
+}
+
+// SetAllowUnknownArguments allows flags that are not defined in the parser,
+// they are treated as values (positionals or subcommand arguments) instead
+// of returning an UnrecognizedArgumentError
+func (parser *ArgParser) SetAllowUnknownArguments(allow bool) {
+	parser.allowUnknown = allow
+}
+
+// SetEnvPrefix sets a prefix to read arguments from environment variables
+// when they are not given, Ex: with prefix APP --max-size is read from
+// APP_MAX_SIZE. Empty prefix disables it.
+func (parser *ArgParser) SetEnvPrefix(prefix string) {
+	parser.envPrefix = strings.ToUpper(prefix)
+}
+
+// SetEnv sets the environment variable from which the argument is read when
+// it is not given in the command line
+func (parser *ArgParser) SetEnv(name string, env string) error {
+	arg, exists := parser.getArgument(name)
+	if !exists {
+		return fmt.Errorf("Argument %s is not defined in parser %s", name, parser.name)
+	}
+	if arg.positional {
+		return fmt.Errorf("Positional argument %s cannot be read from environment", arg.name)
+	}
+	arg.env = env
+	return nil
+}
+
+// SetFromfilePrefixChars sets the characters that mark an argument as a
+// file with more arguments, Ex: with "@" the argument @args.txt is replaced
+// by the arguments in args.txt. Empty disables it.
+func (parser *ArgParser) SetFromfilePrefixChars(chars string) {
+	parser.fromfilePrefixChars = chars
+}
+
+// SetFromfileOnePerLine sets if each line of argument files is an argument,
+// by default lines are split as in StringToArgv
+func (parser *ArgParser) SetFromfileOnePerLine(onePerLine bool) {
+	parser.fromfileOnePerLine = onePerLine
+}
+
+// SetSuggestionDistance sets the maximum edit distance of the names
+// suggested for mistyped arguments and subcommands, 0 disables suggestions.
+// It is also set in the subparsers, the ones added later inherit it.
+func (parser *ArgParser) SetSuggestionDistance(distance int) {
+	for _, p := range parser.allParsers() {
+		p.suggestionDistance = distance
+	}
+}
+
+func (parser *ArgParser) GetSelectedSubparser() string {
+	return *(parser.selectedSubparser)
+}
+
+// GetRemainder returns the arguments after the -- terminator that were not
+// consumed by positional arguments
+func (parser *ArgParser) GetRemainder() []string {
+	return parser.remainder
+}
+
+func (parser *ArgParser) GetHelpArgument() *string {
+	return parser.helpArgument
+}
+
+func (parser *ArgParser) Name() string {
+	return parser.name
+}
+
+func (parser *ArgParser) Description() string {
+	return parser.description
+}
+
+// Path returns the names of the parser and its parents, Ex: tool remote add
+func (parser *ArgParser) Path() string {
+	return parser.path()
+}
+
+// Parent returns the parser of which this is a subparser, nil for the root
+func (parser *ArgParser) Parent() *ArgParser {
+	return parser.parent
+}
+
+func (parser *ArgParser) SubparserRequired() bool {
+	return parser.subparserRequired
+}
+
+// Arguments returns the non positional arguments in definition order
+func (parser *ArgParser) Arguments() []*Argument {
+	args := make([]*Argument, 0, len(parser.arguments))
+	for _, arg := range parser.arguments {
+		if !arg.positional {
+			args = append(args, arg)
+		}
+	}
+	return args
+}
+
+// Positionals returns the positional arguments in the order they are parsed
+func (parser *ArgParser) Positionals() []*Argument {
+	return append([]*Argument{}, parser.posArguments...)
+}
+
+// Argument returns the argument with the given name
+func (parser *ArgParser) Argument(name string) (*Argument, bool) {
+	return parser.getArgument(name)
+}
+
+// lookupArgument returns the argument by its name, with or without prefix,
+// Ex: "--port", "port", or by its shortcut, Ex: "-p" or "p"
+func (parser *ArgParser) lookupArgument(name string) (*Argument, bool) {
+	if arg, ok := parser.getArgument(name); ok {
+		return arg, true
+	}
+
+	if arg, ok := parser.getArgument(string(parser.prefix) + string(parser.prefix) + name); ok {
+		return arg, true
+	}
+
+	shortcut := strings.TrimPrefix(name, string(parser.prefix))
+	if len(shortcut) == 1 {
+		return parser.getArgumentFromShortcut(rune(shortcut[0]))
+	}
+
+	return nil, false
+}
+
+// IsSet checks if the argument was given in the last Parse, in the command
+// line, environment or config file
+func (parser *ArgParser) IsSet(name string) bool {
+	return parser.Source(name) != SourceDefault
+}
+
+// Source returns where the value of the argument came from in the last
+// Parse, SourceDefault if the argument does not exist or was not given
+func (parser *ArgParser) Source(name string) Source {
+	arg, ok := parser.lookupArgument(name)
+	if !ok {
+		return SourceDefault
+	}
+	return parser.sources[arg]
+}
+
+// Groups returns the arguments groups sorted by name
+func (parser *ArgParser) Groups() []*ArgumentsGroup {
+	names := make([]string, 0, len(parser.groups))
+	for name := range parser.groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	groups := make([]*ArgumentsGroup, 0, len(names))
+	for _, name := range names {
+		groups = append(groups, parser.groups[name])
+	}
+	return groups
+}
+
+// Subparsers returns the subparsers sorted by name
+func (parser *ArgParser) Subparsers() []*ArgParser {
+	subparsers := make([]*ArgParser, 0, len(parser.subparsers))
+	for _, name := range parser.subparserNames() {
+		subparsers = append(subparsers, parser.subparsers[name])
+	}
+	return subparsers
+}
+
+func (parser *ArgParser) AddInt(name string, shortcut rune, description string, mandatory bool, action int, defaultValue int, constValue int, checkValue IntArgCheckFunc, group string) (*int, error) {
+
+	arg, err := parser.createArg(name, shortcut, description, mandatory)
+	
+	if err != nil {
+		return nil, err
+	}
+
+	val := newIntValue(defaultValue, constValue, checkValue)
+
+	if arg.mandatory {
+		arg.action = ActionStoreValue
+	} else {
+
+		if isValidAction(action) {
+			arg.action = action
+		} else {
+			return nil, fmt.Errorf("Invalid action")
+		}
+
+		switch action {
+			case ActionStoreTrue:
+				return nil, fmt.Errorf("Store True is only available in booleans")
+
+			case ActionStoreFalse:
+				return nil, fmt.Errorf("Store False is only available in booleans")
+
+			case ActionHelp:
+				return nil, fmt.Errorf("Help is only available in strings")
+
+			case ActionAppend:
+				return nil, fmt.Errorf("Append is only available in slices")
+		}
+	}
+
+	arg.val = val
+
+	err = parser.addArg(arg, group)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return val.value, nil
+}
+
+func (parser *ArgParser) AddString(name string, shortcut rune, description string, mandatory bool, action int, defaultValue string, constValue string, checkValue StringArgCheckFunc, group string) (*string, error){
+	
+	arg, err := parser.createArg(name, shortcut, description, mandatory)
+
+	if err != nil {
+		return nil, err
+	}
+
+	val := newStringValue(defaultValue, constValue, checkValue)
+
+	if arg.mandatory {
+		arg.action = ActionStoreValue
+	} else {
+
+		if isValidAction(action) {
+			arg.action = action
+		} else {
+			return nil, fmt.Errorf("Invalid action")
+		}
+
+		switch action {
+			case ActionStoreTrue:
+				return nil, fmt.Errorf("Store True is only available in booleans")
 
-		opBegin := isLetter(rune(name[0]))
+			case ActionStoreFalse:
+				return nil, fmt.Errorf("Store False is only available in booleans")
 
-		if !posBegin && !opBegin{
-			return false
-		} 
+			case ActionIncrement:
+				return nil, fmt.Errorf("Increment is only available in integers")
 
+			case ActionAppend:
+				return nil, fmt.Errorf("Append is only available in slices")
+		}
 	}
 
-	//only letters and - are available for flags names
-	for _, char := range name {
-		if !isLetter(char) && char != '-'{
-			return false
-		}
+	arg.val = val
+
+	err = parser.addArg(arg, group)
+
+	if err != nil {
+		return nil, err
 	}
 
-	return true
+	return val.value, nil
 }
 
-func (parser *argParser) createArg(name string, shortcut rune, description string, mandatory bool) (*argument, error) {
+func (parser *ArgParser) AddBool(name string, shortcut rune, description string, mandatory bool, action int, defaultValue bool, constValue bool, checkValue BoolArgCheckFunc, group string) (*bool, error) {
 
-	var arg = newArgument(name, shortcut, description, mandatory)
+	arg, err := parser.createArg(name, shortcut, description, mandatory)
 
-	if name == "" && shortcut == NOSHORTCUT {
-		return nil, fmt.Errorf("Argument must have a name and/or shortcut")
+	if err != nil {
+		return nil, err
 	}
 
-	if !parser.isValidFlagName(arg.name) {
-		return nil, fmt.Errorf("Argument name only can be letters and -")
-	}
+	val := newBoolValue(defaultValue, constValue, checkValue)
 
-	if parser.existsArgument(arg.name) {
-		return nil, fmt.Errorf("Argument %s is already defined in parser %s", name, parser.name)
-	}
+	if arg.mandatory {
+		arg.action = ActionStoreValue
+	} else {
 
+		if isValidAction(action) {
+			arg.action = action
+		} else {
+			return nil, fmt.Errorf("Invalid action")
+		}
 
-	if arg.positional {
-		arg.mandatory = true
-		arg.shortcut = NOSHORTCUT
+		switch action {
+			case ActionStoreTrue:
+				val.defaultValue = false
 
-	} else {
-		// check shortcut
-		if shortcut == NOSHORTCUT {
-			arg.shortcut = NOSHORTCUT
-		} else {
-			if !isValidShortcut(shortcut) {
-				return nil, fmt.Errorf("Invalid shorcut, must be a letter")
-			}
+			case ActionStoreFalse:
+				val.defaultValue = true
 
-			argEx, exists := parser.getArgumentFromShortcut(shortcut)
-			if exists {
-				return nil, fmt.Errorf("Shortcut %c (arg %s) is already used in argument %s (parser %s)", shortcut, name, argEx.name, parser.name)
-			}
+			case ActionIncrement:
+				return nil, fmt.Errorf("Increment is only available in integers")
+
+			case ActionHelp:
+				return nil, fmt.Errorf("Help is only available in strings")
+
+			case ActionAppend:
+				return nil, fmt.Errorf("Append is only available in slices")
 		}
 
 	}
 
-	return arg, nil
+	arg.val = val
+
+	err = parser.addArg(arg, group)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return val.value, nil
 }
 
-func (parser *argParser) addArg(arg *argument, group string) error {
-	if group != "" {
-		if !parser.existsGroup(group) {
-			return fmt.Errorf("Group %s is not defined", group)
-		}
-		if arg.positional {
-			return fmt.Errorf("Positional argument %s cannot belong to a group", arg.name)
-		}
-		parser.groups[group].addArgument(arg)
+// AddIntChoices adds an int argument whose value must be one of choices and,
+// if checkValue is not nil, meet it. A default of 0 is allowed even if it is
+// not a choice.
+func (parser *ArgParser) AddIntChoices(name string, shortcut rune, description string, mandatory bool, action int, defaultValue int, constValue int, choices []int, checkValue IntArgCheckFunc, group string) (*int, error) {
+
+	if len(choices) == 0 {
+		return nil, fmt.Errorf("Choices of argument %s cannot be empty", name)
 	}
 
-	parser.arguments = append(parser.arguments, arg)
-	//parser.arguments[arg.name] = arg
+	if defaultValue != 0 && !containsInt(choices, defaultValue) {
+		return nil, fmt.Errorf("Default value %d of argument %s is not one of %s", defaultValue, name, joinInts(choices))
+	}
 
-	if arg.positional {
-		parser.posArguments = append(parser.posArguments, arg)
+	value, err := parser.AddInt(name, shortcut, description, mandatory, action, defaultValue, constValue, checkValue, group)
+
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
+	arg := parser.arguments[len(parser.arguments)-1]
+	val := arg.val.(intValue)
+	val.choices = choices
+	arg.val = val
+
+	return value, nil
 }
 
-// PUBLIC METHODS OF argParser
+// AddStringChoices adds a string argument whose value must be one of choices
+// and, if checkValue is not nil, meet it. An empty default is allowed even
+// if it is not a choice.
+func (parser *ArgParser) AddStringChoices(name string, shortcut rune, description string, mandatory bool, action int, defaultValue string, constValue string, choices []string, checkValue StringArgCheckFunc, group string) (*string, error) {
 
-func (parser *argParser) SetFlagPrefix(prefix rune) {
-	parser.prefix = prefix
-}
+	if len(choices) == 0 {
+		return nil, fmt.Errorf("Choices of argument %s cannot be empty", name)
+	}
 
-func (parser *argParser) SetSubparserRequired(required bool){
-	parser.subparserRequired = required
-}
+	if defaultValue != "" && !containsString(choices, defaultValue) {
+		return nil, fmt.Errorf("Default value %s of argument %s is not one of %s", defaultValue, name, strings.Join(choices, ","))
+	}
 
-func (parser *argParser) GetSelectedSubparser() string {
-	return *(parser.selectedSubparser)
-}
+	value, err := parser.AddString(name, shortcut, description, mandatory, action, defaultValue, constValue, checkValue, group)
 
-func (parser *argParser) GetHelpArgument() *string {
-	return parser.helpArgument
+	if err != nil {
+		return nil, err
+	}
+
+	arg := parser.arguments[len(parser.arguments)-1]
+	val := arg.val.(stringValue)
+	val.choices = choices
+	arg.val = val
+
+	return value, nil
 }
 
-func (parser *argParser) AddInt(name string, shortcut rune, description string, mandatory bool, action int, defaultValue int, constValue int, checkValue IntArgCheckFunc, group string) (*int, error) {
+func (parser *ArgParser) AddFloat(name string, shortcut rune, description string, mandatory bool, action int, defaultValue float64, constValue float64, checkValue FloatArgCheckFunc, group string) (*float64, error) {
 
 	arg, err := parser.createArg(name, shortcut, description, mandatory)
-	
+
 	if err != nil {
 		return nil, err
 	}
 
-	val := newIntValue(defaultValue, constValue, checkValue)
+	val := newFloatValue(defaultValue, constValue, checkValue)
 
 	if arg.mandatory {
 		arg.action = ActionStoreValue
@@ -906,8 +2691,14 @@ func (parser *argParser) AddInt(name string, shortcut rune, description string,
 			case ActionStoreFalse:
 				return nil, fmt.Errorf("Store False is only available in booleans")
 
+			case ActionIncrement:
+				return nil, fmt.Errorf("Increment is only available in integers")
+
 			case ActionHelp:
 				return nil, fmt.Errorf("Help is only available in strings")
+
+			case ActionAppend:
+				return nil, fmt.Errorf("Append is only available in slices")
 		}
 	}
 
@@ -922,15 +2713,22 @@ func (parser *argParser) AddInt(name string, shortcut rune, description string,
 	return val.value, nil
 }
 
-func (parser *argParser) AddString(name string, shortcut rune, description string, mandatory bool, action int, defaultValue string, constValue string, checkValue StringArgCheckFunc, group string) (*string, error){
-	
+// AddDuration adds a time.Duration argument. Values use the Go duration
+// syntax (30s, 1h15m); bare numbers are interpreted in unit, or rejected
+// if unit is 0.
+func (parser *ArgParser) AddDuration(name string, shortcut rune, description string, mandatory bool, action int, defaultValue time.Duration, constValue time.Duration, unit time.Duration, checkValue DurationArgCheckFunc, group string) (*time.Duration, error) {
+
 	arg, err := parser.createArg(name, shortcut, description, mandatory)
 
 	if err != nil {
 		return nil, err
 	}
 
-	val := newStringValue(defaultValue, constValue, checkValue)
+	if unit < 0 {
+		return nil, fmt.Errorf("Invalid unit for duration, it cannot be negative")
+	}
+
+	val := newDurationValue(defaultValue, constValue, unit, checkValue)
 
 	if arg.mandatory {
 		arg.action = ActionStoreValue
@@ -951,6 +2749,12 @@ func (parser *argParser) AddString(name string, shortcut rune, description strin
 
 			case ActionIncrement:
 				return nil, fmt.Errorf("Increment is only available in integers")
+
+			case ActionHelp:
+				return nil, fmt.Errorf("Help is only available in strings")
+
+			case ActionAppend:
+				return nil, fmt.Errorf("Append is only available in slices")
 		}
 	}
 
@@ -965,7 +2769,9 @@ func (parser *argParser) AddString(name string, shortcut rune, description strin
 	return val.value, nil
 }
 
-func (parser *argParser) AddBool(name string, shortcut rune, description string, mandatory bool, action int, defaultValue bool, constValue bool, checkValue BoolArgCheckFunc, group string) (*bool, error) {
+// AddStringSlice adds an argument with ActionAppend, each occurrence appends
+// its value to the slice. The default slice is replaced by the first value.
+func (parser *ArgParser) AddStringSlice(name string, shortcut rune, description string, mandatory bool, defaultValue []string, checkValue StringArgCheckFunc, group string) (*[]string, error) {
 
 	arg, err := parser.createArg(name, shortcut, description, mandatory)
 
@@ -973,34 +2779,94 @@ func (parser *argParser) AddBool(name string, shortcut rune, description string,
 		return nil, err
 	}
 
-	val := newBoolValue(defaultValue, constValue, checkValue)
+	val := newStringSliceValue(defaultValue, checkValue)
 
-	if arg.mandatory {
-		arg.action = ActionStoreValue
-	} else {
+	arg.action = ActionAppend
+	arg.val = val
 
-		if isValidAction(action) {
-			arg.action = action
-		} else {
-			return nil, fmt.Errorf("Invalid action")
-		}
+	err = parser.addArg(arg, group)
 
-		switch action {
-			case ActionStoreTrue:
-				val.defaultValue = false
+	if err != nil {
+		return nil, err
+	}
 
-			case ActionStoreFalse:
-				val.defaultValue = true
+	return val.value, nil
+}
 
-			case ActionIncrement:
-				return nil, fmt.Errorf("Increment is only available in integers")
+// AddIntSlice adds an argument with ActionAppend, each occurrence appends
+// its value to the slice. The default slice is replaced by the first value.
+func (parser *ArgParser) AddIntSlice(name string, shortcut rune, description string, mandatory bool, defaultValue []int, checkValue IntArgCheckFunc, group string) (*[]int, error) {
 
-			case ActionHelp:
-				return nil, fmt.Errorf("Help is only available in strings")
-		}
+	arg, err := parser.createArg(name, shortcut, description, mandatory)
+
+	if err != nil {
+		return nil, err
+	}
+
+	val := newIntSliceValue(defaultValue, checkValue)
+
+	arg.action = ActionAppend
+	arg.val = val
+
+	err = parser.addArg(arg, group)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return val.value, nil
+}
+
+// AddStringMap adds an argument with ActionAppend that collects key=value
+// pairs, several pairs can be given at once separated by commas (a=1,b=2).
+// An empty separator means "=". If rejectDuplicates is set, a key cannot be
+// given twice. The default map is replaced by the first pair.
+func (parser *ArgParser) AddStringMap(name string, shortcut rune, description string, mandatory bool, separator string, rejectDuplicates bool, defaultValue map[string]string, checkValue StringMapArgCheckFunc, group string) (*map[string]string, error) {
+
+	arg, err := parser.createArg(name, shortcut, description, mandatory)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if separator == "" {
+		separator = "="
+	} else if strings.Contains(separator, ",") {
+		return nil, fmt.Errorf("Invalid separator for map, it cannot contain a comma")
+	}
+
+	val := newStringMapValue(defaultValue, separator, rejectDuplicates, checkValue)
+
+	arg.action = ActionAppend
+	arg.val = val
+
+	err = parser.addArg(arg, group)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return val.value, nil
+}
+
+// AddIntMap is like AddStringMap but the values must be integers.
+func (parser *ArgParser) AddIntMap(name string, shortcut rune, description string, mandatory bool, separator string, rejectDuplicates bool, defaultValue map[string]int, checkValue IntMapArgCheckFunc, group string) (*map[string]int, error) {
+
+	arg, err := parser.createArg(name, shortcut, description, mandatory)
+
+	if err != nil {
+		return nil, err
+	}
 
+	if separator == "" {
+		separator = "="
+	} else if strings.Contains(separator, ",") {
+		return nil, fmt.Errorf("Invalid separator for map, it cannot contain a comma")
 	}
 
+	val := newIntMapValue(defaultValue, separator, rejectDuplicates, checkValue)
+
+	arg.action = ActionAppend
 	arg.val = val
 
 	err = parser.addArg(arg, group)
@@ -1012,7 +2878,7 @@ func (parser *argParser) AddBool(name string, shortcut rune, description string,
 	return val.value, nil
 }
 
-func (parser *argParser) AddArgumentsGroup(name string, description string, required bool, exclusive bool) (*argumentsGroup, error) {
+func (parser *ArgParser) AddArgumentsGroup(name string, description string, required bool, exclusive bool) (*ArgumentsGroup, error) {
 	
 	var argsGroup = newArgumentGroup(name, description, required, exclusive, parser)
 
@@ -1029,7 +2895,7 @@ func (parser *argParser) AddArgumentsGroup(name string, description string, requ
 	return argsGroup, nil
 }
 
-func (parser *argParser) AddSubparser(name string, description string, includeHelp bool) (*argParser, error) {
+func (parser *ArgParser) AddSubparser(name string, description string, includeHelp bool) (*ArgParser, error) {
 
 	if name == "" {
 		return nil, fmt.Errorf("Name for subparser cannot be empty")
@@ -1039,12 +2905,18 @@ func (parser *argParser) AddSubparser(name string, description string, includeHe
 		return nil, fmt.Errorf("Subparser %s of %s is already defined", name, parser.name)
 	}
 
+	if parser.parent == nil && name == parser.completionCommand {
+		return nil, fmt.Errorf("Subparser %s of %s is its completion command", name, parser.name)
+	}
+
 	subparser, err := NewArgParser(name, description, includeHelp)
 
 	if err != nil {
 		return nil, err
 	}
 
+	subparser.parent = parser
+	subparser.suggestionDistance = parser.suggestionDistance
 	parser.subparsers[strings.ToLower(name)] = subparser
 
 	return subparser, nil
@@ -1052,14 +2924,14 @@ func (parser *argParser) AddSubparser(name string, description string, includeHe
 
 
 
-func (parser *argParser) Usage() string {
+func (parser *ArgParser) Usage() string {
 
 	var usage bytes.Buffer
 
-	usage.WriteString(fmt.Sprintf("Usage: %s ", parser.name))
+	usage.WriteString(fmt.Sprintf("Usage: %s ", parser.path()))
 
 	
-	for _, grp := range parser.groups {
+	for _, grp := range parser.Groups() {
 		usage.WriteString(fmt.Sprintf("%s ",grp.usage()))
 	}
 
@@ -1075,19 +2947,13 @@ func (parser *argParser) Usage() string {
 	}
 
 	if len(parser.subparsers) > 0 {
-		parserNames := []string{}
-
-		for _, subparser := range parser.subparsers{
-			parserNames = append(parserNames, subparser.name)
-		}
-
-		usage.WriteString(fmt.Sprintf("{%s} ...", strings.Join(parserNames, ",")))
+		usage.WriteString(fmt.Sprintf("{%s} ...", strings.Join(parser.subparserNames(), ",")))
 	}
 
 	return usage.String()
 }
 
-func (parser *argParser) Help() string {
+func (parser *ArgParser) Help() string {
 
 	var help bytes.Buffer
 
@@ -1107,13 +2973,7 @@ func (parser *argParser) Help() string {
 	}
 
 	if len(parser.subparsers) > 0 {
-		parserNames := []string{}
-
-		for _, subparser := range parser.subparsers{
-			parserNames = append(parserNames, subparser.name)
-		}
-
-		help.WriteString(fmt.Sprintf("{%s}\tsubcommands\n", strings.Join(parserNames, ",")))
+		help.WriteString(fmt.Sprintf("{%s}\tsubcommands\n", strings.Join(parser.subparserNames(), ",")))
 	}
 
 	if len(parser.arguments) > 0 {
@@ -1129,19 +2989,63 @@ func (parser *argParser) Help() string {
 	return help.String()
 }
 
-// Parse parse arguments and return the parameters
-func (parser *argParser) Parse(arguments []string) (error) {
+// Parse parse arguments and return the parameters. ErrHelp is returned if
+// the help argument was given and ErrCompletion if it was called by a
+// completion script with the command set by SetCompletionCommand, which
+// reads the candidates from the standard output, so they are not errors to
+// be printed. Ex:
+//
+//	err := parser.Parse(nil)
+//	if errors.Is(err, argparse.ErrCompletion) {
+//		os.Exit(0)
+//	}
+func (parser *ArgParser) Parse(arguments []string) (error) {
+	if arguments == nil {
+		arguments = os.Args
+	}
+
+	// called by a completion script
+	if parser.isCompletion(arguments) {
+		return parser.printCompletion(arguments)
+	}
+
+	err := parser.parse(arguments)
+	if err != nil {
+		return err
+	}
+
+	for _, bind := range parser.bindings {
+		bind()
+	}
+
+	return nil
+}
+
+func (parser *ArgParser) parse(arguments []string) (error) {
 	
 	argStr := ""
-	var errMessage bytes.Buffer
+	var errs []error
 	currentArgs := arguments
 	index := 0
-	var currentArg *argument = nil
-	argsSet := map[string]*argument{}
+	var currentArg *Argument = nil
+	argsSet := map[*Argument]Source{}
 	positionalIndex := 0
 	numNonProcessesPositionals := 0
+	terminated := false
 
 	*(parser.selectedSubparser) = ""
+	parser.remainder = nil
+	parser.config = nil
+	parser.sources = argsSet
+
+	// a Parse of the root parser clears the subparsers, the ones that are not
+	// selected this time must not report the sources of a previous Parse
+	if parser.parent == nil {
+		for _, subparser := range parser.allParsers()[1:] {
+			*(subparser.selectedSubparser) = ""
+			subparser.sources = map[*Argument]Source{}
+		}
+	}
 
 	parser.setDefaultValues()
 
@@ -1154,6 +3058,15 @@ func (parser *argParser) Parse(arguments []string) (error) {
 	}
 
 	currentArgs = currentArgs[1:]
+
+	if parser.fromfilePrefixChars != "" {
+		expanded, err := parser.expandFromfiles(currentArgs, nil)
+		if err != nil {
+			return err
+		}
+		currentArgs = expanded
+	}
+
 	numNonProcessesPositionals = len(parser.posArguments)
 
 	ParseLoop: for index = 0; index < len(currentArgs) - numNonProcessesPositionals; index++{
@@ -1168,6 +3081,7 @@ func (parser *argParser) Parse(arguments []string) (error) {
 					if err != nil{
 						return err
 					}
+					argsSet[parser.posArguments[positionalIndex]] = SourceCommandLine
 					positionalIndex++
 					numNonProcessesPositionals--
 				} else {
@@ -1179,7 +3093,7 @@ func (parser *argParser) Parse(arguments []string) (error) {
 				currentArg, _ = parser.getArgument(argStr)
 
 				switch currentArg.action{
-					case ActionStoreValue:
+					case ActionStoreValue, ActionAppend:
 						if index < len(currentArgs) - 1 {
 							index++
 							err := currentArg.set(currentArgs[index])
@@ -1187,7 +3101,7 @@ func (parser *argParser) Parse(arguments []string) (error) {
 								return err
 							}
 						} else {
-							return fmt.Errorf("No value for argument %s", currentArg.name)
+							return parser.missingValue(currentArg)
 						}
 					case ActionStoreTrue:
 						currentArg.setTrue()
@@ -1203,13 +3117,13 @@ func (parser *argParser) Parse(arguments []string) (error) {
 
 					case ActionHelp:
 						currentArg.set(parser.Help())
-						return fmt.Errorf("Help")
+						return ErrHelp
 
 					default:
 						fmt.Printf("This is a top secret message, or maybe a bug\n")
 
 				}
-				argsSet[currentArg.name] = currentArg
+				argsSet[currentArg] = currentArg.commandLineSource()
 				currentArg = nil
 				continue
 				
@@ -1217,7 +3131,7 @@ func (parser *argParser) Parse(arguments []string) (error) {
 				currentArg, _ = parser.getArgumentFromShortcut(rune(argStr[1]))
 
 				switch currentArg.action{
-					case ActionStoreValue:
+					case ActionStoreValue, ActionAppend:
 						if index < len(currentArgs) - 1 {
 							index++
 							err := currentArg.set(currentArgs[index])
@@ -1225,7 +3139,7 @@ func (parser *argParser) Parse(arguments []string) (error) {
 								return err
 							}
 						} else {
-							return fmt.Errorf("No value for argument %s", currentArg.name)
+							return parser.missingValue(currentArg)
 						}
 					case ActionStoreTrue:
 						currentArg.setTrue()
@@ -1241,28 +3155,30 @@ func (parser *argParser) Parse(arguments []string) (error) {
 
 					case ActionHelp:
 						currentArg.set(parser.Help())
-						return fmt.Errorf("Help")
+						return ErrHelp
 
 					default:
 						fmt.Printf("This is a top secret message, or maybe a bug\n")
 
 				}
-				argsSet[currentArg.name] = currentArg
+				argsSet[currentArg] = currentArg.commandLineSource()
 				currentArg = nil
 				continue
 
 			case ShortcutGroupCategory:
 
-				for i := 1; i < len(argStr); i++ {
+				GroupLoop: for i := 1; i < len(argStr); i++ {
 					currentArg, _ = parser.getArgumentFromShortcut(rune(argStr[i]))
 
 					switch currentArg.action{
-						case ActionStoreValue:
+						case ActionStoreValue, ActionAppend:
 							if i < len(argStr) - 1 {
 								err := currentArg.set(argStr[(i+1):])
 								if err != nil {
 									return err
 								}
+								argsSet[currentArg] = currentArg.commandLineSource()
+								break GroupLoop
 							} else if index < len(currentArgs) - 1 {
 								index++
 								err := currentArg.set(currentArgs[index])
@@ -1271,7 +3187,7 @@ func (parser *argParser) Parse(arguments []string) (error) {
 								}
 
 							} else {
-								return fmt.Errorf("No value for argument %s", currentArg.name)
+								return parser.missingValue(currentArg)
 							}
 							break
 						case ActionStoreTrue:
@@ -1288,13 +3204,13 @@ func (parser *argParser) Parse(arguments []string) (error) {
 
 						case ActionHelp:
 							currentArg.set(parser.Help())
-							return fmt.Errorf("Help")
+							return ErrHelp
 
 						default:
 							fmt.Printf("This is a top secret message, or maybe a bug\n")
 					}
+					argsSet[currentArg] = currentArg.commandLineSource()
 				}
-				argsSet[currentArg.name] = currentArg
 				currentArg = nil
 				continue
 
@@ -1307,7 +3223,7 @@ func (parser *argParser) Parse(arguments []string) (error) {
 				currentArg, _ = parser.getArgument(argName)
 
 				switch currentArg.action{
-					case ActionStoreValue:
+					case ActionStoreValue, ActionAppend:
 						err := currentArg.set(argValue)
 						if err != nil {
 							return err
@@ -1326,13 +3242,13 @@ func (parser *argParser) Parse(arguments []string) (error) {
 
 					case ActionHelp:
 						currentArg.set(parser.Help())
-						return fmt.Errorf("Help")
+						return ErrHelp
 
 					default:
 						fmt.Printf("This is a top secret message, or maybe a bug\n")
 
 				}
-				argsSet[currentArg.name] = currentArg
+				argsSet[currentArg] = currentArg.commandLineSource()
 				currentArg = nil
 				continue
 
@@ -1345,7 +3261,7 @@ func (parser *argParser) Parse(arguments []string) (error) {
 				currentArg, _ = parser.getArgumentFromShortcut(rune(argShortcut))
 
 				switch currentArg.action {
-					case ActionStoreValue:
+					case ActionStoreValue, ActionAppend:
 						err := currentArg.set(argValue)
 						if err != nil {
 							return err
@@ -1364,13 +3280,13 @@ func (parser *argParser) Parse(arguments []string) (error) {
 
 					case ActionHelp:
 						currentArg.set(parser.Help())
-						return fmt.Errorf("Help")
+						return ErrHelp
 
 					default:
 						fmt.Printf("This is a top secret message, or maybe a bug\n")
 
 				}
-				argsSet[currentArg.name] = currentArg
+				argsSet[currentArg] = currentArg.commandLineSource()
 				currentArg = nil
 				continue
 
@@ -1384,7 +3300,7 @@ func (parser *argParser) Parse(arguments []string) (error) {
 					currentArg, _ = parser.getArgumentFromShortcut(rune(argStr[i]))
 
 					switch currentArg.action{
-						case ActionStoreValue:
+						case ActionStoreValue, ActionAppend:
 							if i < len(groupShortcuts) - 1 {
 								err := currentArg.set(argStr[(i+1):])
 								if err != nil {
@@ -1411,12 +3327,12 @@ func (parser *argParser) Parse(arguments []string) (error) {
 
 						case ActionHelp:
 							currentArg.set(parser.Help())
-							return fmt.Errorf("Help")
+							return ErrHelp
 
 						default:
 							fmt.Printf("This is a top secret message, or maybe a bug\n")
 					}
-					argsSet[currentArg.name] = currentArg
+					argsSet[currentArg] = currentArg.commandLineSource()
 				}
 				currentArg = nil
 				continue
@@ -1427,7 +3343,7 @@ func (parser *argParser) Parse(arguments []string) (error) {
 				currentArg, _ = parser.getArgument(argName)
 
 				switch currentArg.action{
-					case ActionStoreValue:
+					case ActionStoreValue, ActionAppend:
 						if index < len(currentArgs) - 1 {
 							index++
 							err := currentArg.set(currentArgs[index])
@@ -1435,7 +3351,7 @@ func (parser *argParser) Parse(arguments []string) (error) {
 								return err
 							}
 						} else {
-							return fmt.Errorf("No value for argument %s", currentArg.name)
+							return parser.missingValue(currentArg)
 						}
 					case ActionStoreTrue:
 						currentArg.setTrue()
@@ -1451,13 +3367,13 @@ func (parser *argParser) Parse(arguments []string) (error) {
 
 					case ActionHelp:
 						currentArg.set(parser.Help())
-						return fmt.Errorf("Help")
+						return ErrHelp
 
 					default:
 						fmt.Printf("This is a top secret message, or maybe a bug\n")
 
 				}
-				argsSet[currentArg.name] = currentArg
+				argsSet[currentArg] = currentArg.commandLineSource()
 				currentArg = nil
 				continue
 
@@ -1467,7 +3383,7 @@ func (parser *argParser) Parse(arguments []string) (error) {
 				currentArg, _ = parser.getArgumentFromShortcut(rune(argShortcut))
 
 				switch currentArg.action{
-					case ActionStoreValue:
+					case ActionStoreValue, ActionAppend:
 						if index < len(currentArgs) - 1 {
 							index++
 							err := currentArg.set(currentArgs[index])
@@ -1475,7 +3391,7 @@ func (parser *argParser) Parse(arguments []string) (error) {
 								return err
 							}
 						} else {
-							return fmt.Errorf("No value for argument %s", currentArg.name)
+							return parser.missingValue(currentArg)
 						}
 					case ActionStoreTrue:
 						currentArg.setTrue()
@@ -1491,13 +3407,13 @@ func (parser *argParser) Parse(arguments []string) (error) {
 
 					case ActionHelp:
 						currentArg.set(parser.Help())
-						return fmt.Errorf("Help")
+						return ErrHelp
 
 					default:
 						fmt.Printf("This is a top secret message, or maybe a bug\n")
 
 				}
-				argsSet[currentArg.name] = currentArg
+				argsSet[currentArg] = currentArg.commandLineSource()
 				currentArg = nil
 				continue
 			
@@ -1509,7 +3425,7 @@ func (parser *argParser) Parse(arguments []string) (error) {
 					currentArg, _ = parser.getArgumentFromShortcut(rune(argStr[i]))
 
 					switch currentArg.action{
-						case ActionStoreValue:
+						case ActionStoreValue, ActionAppend:
 							if i < len(groupShortcuts) - 1 {
 								err := currentArg.set(argStr[(i+1):])
 								if err != nil {
@@ -1522,7 +3438,7 @@ func (parser *argParser) Parse(arguments []string) (error) {
 									return err
 								}
 							} else {
-								return fmt.Errorf("No value for argument %s", currentArg.name)
+								return parser.missingValue(currentArg)
 							}
 							break
 						case ActionStoreTrue:
@@ -1539,16 +3455,24 @@ func (parser *argParser) Parse(arguments []string) (error) {
 
 						case ActionHelp:
 							currentArg.set(parser.Help())
-							return fmt.Errorf("Help")
+							return ErrHelp
 
 						default:
 							fmt.Printf("This is a top secret message, or maybe a bug\n")
 					}
-					argsSet[currentArg.name] = currentArg
+					argsSet[currentArg] = currentArg.commandLineSource()
 				}
 				currentArg = nil
 				continue
 			
+			case UnknownCategory:
+				return parser.unrecognizedArgument(argStr)
+
+			case TerminatorCategory:
+				index++
+				terminated = true
+				break ParseLoop
+
 			default:
 				fmt.Printf("What? Are you seeing me? I'm a fail in code (switch category)\n")
 				return fmt.Errorf("Fail in code")
@@ -1556,7 +3480,7 @@ func (parser *argParser) Parse(arguments []string) (error) {
 	}
 
 	//if only there are one argument, check if it is a help argument
-	if len(currentArgs) == 1 && numNonProcessesPositionals == 1 {
+	if !terminated && len(currentArgs) == 1 && numNonProcessesPositionals == 1 {
 		argStr = currentArgs[0]
 		category := parser.getArgumentCategory(argStr)
 
@@ -1565,7 +3489,7 @@ func (parser *argParser) Parse(arguments []string) (error) {
 
 			if currentArg.action == ActionHelp {
 				currentArg.set(parser.Help())
-				return fmt.Errorf("Help")
+				return ErrHelp
 			}
 						
 		} else if category == ShortcutCategory {
@@ -1574,7 +3498,7 @@ func (parser *argParser) Parse(arguments []string) (error) {
 
 			if currentArg.action == ActionHelp {
 				currentArg.set(parser.Help())
-				return fmt.Errorf("Help")
+				return ErrHelp
 			}
 
 		}
@@ -1582,43 +3506,69 @@ func (parser *argParser) Parse(arguments []string) (error) {
 
 
 	// process the rest of positional arguments
-	for ; positionalIndex < numNonProcessesPositionals && index < len(currentArgs); positionalIndex++{
+	for positionalIndex < len(parser.posArguments) && index < len(currentArgs) {
+		argStr = currentArgs[index]
+
+		if !terminated {
+			switch parser.getArgumentCategory(argStr) {
+				case TerminatorCategory:
+					terminated = true
+					index++
+					continue
 
-		err := parser.posArguments[positionalIndex].set(currentArgs[index])
+				case UnknownCategory:
+					return parser.unrecognizedArgument(argStr)
+			}
+		}
+
+		err := parser.posArguments[positionalIndex].set(argStr)
 		if err != nil{
 			return err
 		}
+		argsSet[parser.posArguments[positionalIndex]] = SourceCommandLine
+		positionalIndex++
 		index++
 	}
 
 	// check if positional arguments were set
 	if positionalIndex < len(parser.posArguments) {
-		return fmt.Errorf("Too few arguments were provided")
+		for _, arg := range parser.posArguments[positionalIndex:] {
+			errs = append(errs, &MissingArgumentError{Argument: arg.name, Parser: parser.path()})
+		}
+		return errors.Join(errs...)
+	}
+
+	// arguments not given in command line are read from environment
+	err := parser.setFromEnvironment(argsSet)
+	if err != nil {
+		return err
+	}
+
+	// and then from config file
+	err = parser.setFromConfig(argsSet)
+	if err != nil {
+		return err
 	}
 
 	// check if mandatory arguments were set
 	for _, arg := range parser.arguments {
 		if !arg.mandatory || arg.positional {continue}
 
-		_, wasSet := argsSet[arg.name]
+		_, wasSet := argsSet[arg]
 		if !wasSet{
-			errMessage.WriteString(fmt.Sprintf("argument %s has no value\n", arg.name))
+			errs = append(errs, &MissingArgumentError{Argument: arg.name, Shortcut: arg.shortcut, Parser: parser.path()})
 		}
 	}
 
-	/*if errMessage.Len() > 0 {
-		return nil, fmt.Errorf("%s", errMessage.String())
-	}*/
-
 
 	// GROUPS
 	// check requirements of the arguments groups
 	for _, argGroup := range parser.groups {
 
-		argGroupSet := make([]*argument, 0, 8)
+		argGroupSet := make([]*Argument, 0, 8)
 
 		for _, arg := range argGroup.arguments {
-			_, wasSet := argsSet[arg.name]
+			_, wasSet := argsSet[arg]
 			if wasSet{
 				argGroupSet = append(argGroupSet, arg)
 			}
@@ -1629,56 +3579,76 @@ func (parser *argParser) Parse(arguments []string) (error) {
 			
 			argNames := make([]string,0,8)
 			for _, arg := range argGroup.arguments {
-				argNames = append(argNames, fmt.Sprintf("%s[-%c]",arg.name, arg.shortcut))
+				argNames = append(argNames, argDisplay(arg.name, arg.shortcut))
 			}
-			errMessage.WriteString(fmt.Sprintf("no argument from group \"%s\" (%s) was specified\n", argGroup.name, strings.Join(argNames,",")))
+			errs = append(errs, &RequiredGroupError{Group: argGroup.name, Parser: parser.path(), Arguments: argNames})
 	
 		} else if len(argGroupSet) > 1 && argGroup.exclusive {
 			argNames := make([]string,0,8)
 			for _, arg := range argGroupSet {
-				argNames = append(argNames, fmt.Sprintf("%s[-%c]",arg.name, arg.shortcut))
+				argNames = append(argNames, argDisplay(arg.name, arg.shortcut))
 			}
 
-			errMessage.WriteString(fmt.Sprintf("more than one argument of group \"%s\" was specified (%s)\n", argGroup.name, strings.Join(argNames,",")))
+			errs = append(errs, &ExclusiveGroupError{Group: argGroup.name, Parser: parser.path(), Arguments: argNames})
 		}
 	}
 
-	if errMessage.Len() > 0 {
-		return fmt.Errorf("%s", errMessage.String())
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	// after -- the rest of arguments are not parsed
+	if terminated {
+		parser.remainder = currentArgs[index:]
 	}
 
 	// check if there are enough arguments for subparser
-	if index < len(currentArgs) {
+	if !terminated && index < len(currentArgs) {
 		subparser, ok := parser.getSubparser(currentArgs[index])
 
 		if ok {
 			*(parser.selectedSubparser) = subparser.name
 			// parse subparser
+			if parser.config != nil {
+				subparser.parentConfig = parser.config.sections[subparser.name]
+			}
 			err := subparser.Parse(currentArgs[index:])
+			subparser.parentConfig = nil
 
-			if fmt.Sprintf("%s",err) == "Help"{
+			if errors.Is(err, ErrHelp) {
 				help := subparser.GetHelpArgument()
-				*(parser.helpArgument) = *help
+				if parser.helpArgument != nil && help != nil {
+					*(parser.helpArgument) = *help
+				}
 				return err
 			}
 
+			if err != nil {
+				// errors of nested subparsers are already wrapped
+				var cmdErr *CommandError
+				if errors.As(err, &cmdErr) {
+					return err
+				}
+				return &CommandError{Path: subparser.path(), Err: err}
+			}
+
+			return nil
+
+		} else if len(parser.subparsers) > 0 {
+			return parser.unknownSubcommand(currentArgs[index])
 		}
 
 	}
 
 	if parser.subparserRequired {
-		names := make([]string,0,8)
-		for _, subparser := range parser.subparsers {
-			names = append(names, subparser.name)
-		}
-		return fmt.Errorf("no subcommand was specified (%s)\n", strings.Join(names,","))
+		return &MissingSubcommandError{Parser: parser.path(), Subcommands: parser.subparserNames()}
 	}
 
 
 	return nil
 }
 
-// ==CLASS argParser END==
+// ==CLASS ArgParser END==
 
 
 // function to split a string into different arguments
@@ -0,0 +1,1478 @@
+package argparse
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAddFloat(t *testing.T) {
+	parser, err := NewArgParser("prog", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	positive := func(value float64) bool { return value > 0 }
+	ratio, err := parser.AddFloat("--ratio", 'r', "compression ratio", false, ActionStoreValue, 0.5, 0, positive, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	full, err := parser.AddFloat("--full", NOSHORTCUT, "no compression", false, ActionStoreConst, 0, 1, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the same parser is reused, the values are reset before each Parse
+	steps := []struct {
+		args  []string
+		ratio float64
+		full  float64
+		err   string
+	}{
+		{[]string{"prog", "--ratio", "0.25"}, 0.25, 0, ""},
+		{[]string{"prog"}, 0.5, 0, ""},
+		{[]string{"prog", "-r", "1e-3", "--full"}, 0.001, 1, ""},
+		{[]string{"prog", "--ratio", "half"}, 0, 0, "must be a float"},
+		{[]string{"prog", "--ratio", "-2"}, 0, 0, "must meet custom restriction"},
+	}
+
+	for _, step := range steps {
+		err := parser.Parse(step.args)
+		if step.err != "" {
+			if err == nil || !strings.Contains(err.Error(), step.err) {
+				t.Errorf("%v: got error %v, want %q", step.args, err, step.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: unexpected error %s", step.args, err)
+		} else if *ratio != step.ratio || *full != step.full {
+			t.Errorf("%v: got ratio=%g full=%g, want ratio=%g full=%g", step.args, *ratio, *full, step.ratio, step.full)
+		}
+	}
+}
+
+func TestAddFloatActions(t *testing.T) {
+	for _, action := range []int{ActionStoreTrue, ActionStoreFalse, ActionIncrement, ActionHelp} {
+		parser, err := NewArgParser("prog", "", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = parser.AddFloat("--ratio", NOSHORTCUT, "", false, action, 0, 0, nil, "")
+		if err == nil {
+			t.Errorf("action %d: expected an error", action)
+		}
+	}
+}
+
+func TestAddDuration(t *testing.T) {
+	tests := []struct {
+		unit  time.Duration
+		value string
+		want  time.Duration
+	}{
+		{0, "30s", 30 * time.Second},
+		{0, "1h15m", 75 * time.Minute},
+		{time.Second, "90", 90 * time.Second},
+		{time.Millisecond, "1.5", 1500 * time.Microsecond},
+		{time.Second, "2m", 2 * time.Minute},
+	}
+
+	for _, test := range tests {
+		parser, err := NewArgParser("prog", "", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		timeout, err := parser.AddDuration("--timeout", 't', "", false, ActionStoreValue, 0, 0, test.unit, nil, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = parser.Parse([]string{"prog", "-t", test.value})
+		if err != nil {
+			t.Errorf("%s in %s: unexpected error %s", test.value, test.unit, err)
+		} else if *timeout != test.want {
+			t.Errorf("%s in %s: got %s, want %s", test.value, test.unit, *timeout, test.want)
+		}
+	}
+}
+
+func TestAddDurationInvalid(t *testing.T) {
+	parser, err := NewArgParser("prog", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	short := func(value time.Duration) bool { return value <= time.Hour }
+	_, err = parser.AddDuration("--timeout", NOSHORTCUT, "", false, ActionStoreValue, time.Minute, 0, 0, short, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// bare numbers are rejected without a unit
+	for value, reason := range map[string]string{"90": "must be a duration", "soon": "must be a duration", "2h": "must meet custom restriction"} {
+		err := parser.Parse([]string{"prog", "--timeout", value})
+		if err == nil || !strings.HasSuffix(err.Error(), reason) {
+			t.Errorf("%s: got error %v, want %q", value, err, reason)
+		}
+	}
+
+	_, err = parser.AddDuration("--interval", NOSHORTCUT, "", false, ActionStoreValue, 0, 0, -time.Second, nil, "")
+	if err == nil {
+		t.Errorf("expected an error for a negative unit")
+	}
+}
+
+func TestAddDurationRange(t *testing.T) {
+	parser, err := NewArgParser("prog", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	timeout, err := parser.AddDuration("--timeout", NOSHORTCUT, "", false, ActionStoreValue, 0, 0, time.Second, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Inf and NaN are not durations, huge numbers overflow a time.Duration
+	for value, reason := range map[string]string{
+		"inf":        "must be a duration",
+		"-Inf":       "must be a duration",
+		"NaN":        "must be a duration",
+		"1e300":      "is out of range",
+		"-1e300":     "is out of range",
+		"9223372037": "is out of range",
+	} {
+		err := parser.Parse([]string{"prog", "--timeout", value})
+		var invalid *InvalidValueError
+		if !errors.As(err, &invalid) || !strings.HasSuffix(err.Error(), reason) {
+			t.Errorf("%s: got error %v, want an InvalidValueError %q", value, err, reason)
+		}
+	}
+
+	err = parser.Parse([]string{"prog", "--timeout", "-9223372036"})
+	if err != nil {
+		t.Errorf("unexpected error %s", err)
+	} else if *timeout != -9223372036*time.Second {
+		t.Errorf("got %s, want %s", *timeout, -9223372036*time.Second)
+	}
+}
+
+func TestHelpDurationDefault(t *testing.T) {
+	parser, err := NewArgParser("prog", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = parser.AddDuration("--timeout", NOSHORTCUT, "time to wait", false, ActionStoreValue, 90*time.Second, 0, 0, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = parser.AddInt("--retries", NOSHORTCUT, "times to retry", false, ActionStoreValue, 3, 0, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	help := parser.Help()
+	if !strings.Contains(help, "time to wait (default: 1m30s)\n") {
+		t.Errorf("the default of --timeout is not in the help:\n%s", help)
+	}
+	if !strings.Contains(help, "times to retry (default: 3)\n") {
+		t.Errorf("the default of --retries is not in the help:\n%s", help)
+	}
+}
+
+func TestAppend(t *testing.T) {
+	parser, err := NewArgParser("prog", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = parser.AddBool("--verbose", 'v', "", false, ActionStoreTrue, false, false, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	positive := func(value int) bool { return value > 0 }
+	ports, err := parser.AddIntSlice("--port", 'p', "", false, []int{8080}, positive, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags, err := parser.AddStringSlice("--tag", 't', "", false, nil, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every way of giving a value appends it, the default is replaced by the
+	// first one and restored in the next Parse
+	steps := []struct {
+		args  []string
+		ports []int
+		tags  []string
+	}{
+		{[]string{"prog", "--port", "1", "-p", "2", "--port=3", "-p4", "-vp5"}, []int{1, 2, 3, 4, 5}, []string{}},
+		{[]string{"prog", "-t", "a", "--tag", "b c", "-t", "a"}, []int{8080}, []string{"a", "b c", "a"}},
+		{[]string{"prog", "-p", "1"}, []int{1}, []string{}},
+	}
+
+	for _, step := range steps {
+		err := parser.Parse(step.args)
+		if err != nil {
+			t.Errorf("%v: unexpected error %s", step.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(*ports, step.ports) || !reflect.DeepEqual(*tags, step.tags) {
+			t.Errorf("%v: got ports=%v tags=%q, want ports=%v tags=%q", step.args, *ports, *tags, step.ports, step.tags)
+		}
+	}
+
+	for _, args := range [][]string{{"prog", "--port", "x"}, {"prog", "-p", "1", "-p", "0"}, {"prog", "--port"}} {
+		if err := parser.Parse(args); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
+
+func TestAppendOnlyInSlices(t *testing.T) {
+	parser, err := NewArgParser("prog", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := parser.AddInt("--port", NOSHORTCUT, "", false, ActionAppend, 0, 0, nil, ""); err == nil {
+		t.Errorf("AddInt accepts ActionAppend")
+	}
+	if _, err := parser.AddString("--tag", NOSHORTCUT, "", false, ActionAppend, "", "", nil, ""); err == nil {
+		t.Errorf("AddString accepts ActionAppend")
+	}
+	if _, err := parser.AddBool("--verbose", NOSHORTCUT, "", false, ActionAppend, false, false, nil, ""); err == nil {
+		t.Errorf("AddBool accepts ActionAppend")
+	}
+}
+
+func TestSplitPairs(t *testing.T) {
+	pairs, err := splitPairs("a=1,b=x=y,c=", "=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][2]string{{"a", "1"}, {"b", "x=y"}, {"c", ""}}
+	if !reflect.DeepEqual(pairs, want) {
+		t.Errorf("got %q, want %q", pairs, want)
+	}
+
+	for _, value := range []string{"a", "=1", "a=1,", "a=1,b"} {
+		if _, err := splitPairs(value, "="); err == nil {
+			t.Errorf("%s: expected an error", value)
+		}
+	}
+}
+
+func TestMaps(t *testing.T) {
+	parser, err := NewArgParser("prog", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	labels, err := parser.AddStringMap("--label", 'l', "", false, ":", false, map[string]string{"env": "dev"}, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	limits, err := parser.AddIntMap("--limit", NOSHORTCUT, "", false, "", true, nil, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = parser.Parse([]string{"prog", "-l", "app:web,tier:front", "--label", "app:api", "--limit", "cpu=2,mem=0x100"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"app": "api", "tier": "front"}; !reflect.DeepEqual(*labels, want) {
+		t.Errorf("got labels %v, want %v", *labels, want)
+	}
+	if want := map[string]int{"cpu": 2, "mem": 256}; !reflect.DeepEqual(*limits, want) {
+		t.Errorf("got limits %v, want %v", *limits, want)
+	}
+
+	err = parser.Parse([]string{"prog"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"env": "dev"}; !reflect.DeepEqual(*labels, want) {
+		t.Errorf("got labels %v, want the default %v", *labels, want)
+	}
+
+	invalid := map[string]string{
+		"--limit=cpu=2,cpu=3": "key cpu is repeated",
+		"--limit=cpu=two":     "value of key cpu must be an integer",
+		"--label=app=web":     "must be a list of key:value pairs",
+	}
+	for arg, reason := range invalid {
+		err := parser.Parse([]string{"prog", arg})
+		if err == nil || !strings.HasSuffix(err.Error(), reason) {
+			t.Errorf("%s: got error %v, want %q", arg, err, reason)
+		}
+	}
+}
+
+func TestMapSet(t *testing.T) {
+	check := func(key string, value int) bool { return key != "bad" }
+
+	tests := []struct {
+		args  []string
+		want  map[string]int
+		valid bool
+	}{
+		{[]string{"prog"}, map[string]int{"a": 1}, true},
+		{[]string{"prog", "--limits", "a=2"}, map[string]int{"a": 2}, true},
+		{[]string{"prog", "--limits", "b=2,c=3"}, map[string]int{"b": 2, "c": 3}, true},
+		{[]string{"prog", "--limits", "b=2", "--limits", "c=3"}, map[string]int{"b": 2, "c": 3}, true},
+		{[]string{"prog", "--limits", "b=2", "--limits", "b=3"}, nil, false},
+		{[]string{"prog", "--limits", "b=2,b=3"}, nil, false},
+		{[]string{"prog", "--limits", "bad=2"}, map[string]int{"a": 1}, false},
+		{[]string{"prog", "--limits", "b=x"}, map[string]int{"a": 1}, false},
+		{[]string{"prog", "--limits", "b"}, map[string]int{"a": 1}, false},
+	}
+
+	for _, test := range tests {
+		parser, err := NewArgParser("prog", "", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		limits, err := parser.AddIntMap("--limits", NOSHORTCUT, "", false, "", true, map[string]int{"a": 1}, check, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = parser.Parse(test.args)
+		if test.valid && err != nil {
+			t.Errorf("%v: unexpected error %s", test.args, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%v: expected an error", test.args)
+		}
+		// a failed value does not clear the default
+		if test.want != nil && !reflect.DeepEqual(*limits, test.want) {
+			t.Errorf("%v: got %v, want %v", test.args, *limits, test.want)
+		}
+	}
+}
+
+func TestChoices(t *testing.T) {
+	parser, err := NewArgParser("prog", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	format, err := parser.AddStringChoices("--format", 'f', "output format", false, ActionStoreValue, "text", "", []string{"json", "yaml", "text"}, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	level, err := parser.AddIntChoices("level", NOSHORTCUT, "compression level", true, ActionStoreValue, 0, 0, []int{1, 5, 9}, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = parser.Parse([]string{"prog", "-f", "yaml", "5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *format != "yaml" || *level != 5 {
+		t.Errorf("got format=%s level=%d, want format=yaml level=5", *format, *level)
+	}
+
+	err = parser.Parse([]string{"prog", "--format", "xml", "5"})
+	if err == nil || !strings.HasSuffix(err.Error(), "must be one of json,yaml,text") {
+		t.Errorf("got error %v, want the list of formats", err)
+	}
+	err = parser.Parse([]string{"prog", "3"})
+	if err == nil || !strings.HasSuffix(err.Error(), "must be one of 1,5,9") {
+		t.Errorf("got error %v, want the list of levels", err)
+	}
+
+	for _, text := range []string{parser.Usage(), parser.Help()} {
+		if !strings.Contains(text, "{json,yaml,text}") || !strings.Contains(text, "{1,5,9}") {
+			t.Errorf("the choices are not rendered:\n%s", text)
+		}
+	}
+}
+
+func TestChoicesInvalid(t *testing.T) {
+	parser, err := NewArgParser("prog", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := parser.AddStringChoices("--format", NOSHORTCUT, "", false, ActionStoreValue, "", "", nil, nil, ""); err == nil {
+		t.Errorf("expected an error for empty choices")
+	}
+	if _, err := parser.AddStringChoices("--format", NOSHORTCUT, "", false, ActionStoreValue, "xml", "", []string{"json"}, nil, ""); err == nil {
+		t.Errorf("expected an error for a default that is not a choice")
+	}
+	if _, err := parser.AddIntChoices("--level", NOSHORTCUT, "", false, ActionStoreValue, 3, 0, []int{1, 5}, nil, ""); err == nil {
+		t.Errorf("expected an error for a default that is not a choice")
+	}
+}
+
+func TestUnrecognizedArgument(t *testing.T) {
+	newParser := func(allowUnknown bool) (*ArgParser, *string) {
+		parser, err := NewArgParser("prog", "", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parser.SetAllowUnknownArguments(allowUnknown)
+		_, err = parser.AddBool("--verbose", 'v', "", false, ActionStoreTrue, false, false, nil, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		file, err := parser.AddString("file", NOSHORTCUT, "", true, ActionStoreValue, "", "", nil, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parser, file
+	}
+
+	tests := []struct {
+		args []string
+		want *UnrecognizedArgumentError
+	}{
+		{[]string{"prog", "--verbos", "a.txt"}, &UnrecognizedArgumentError{Argument: "--verbos", Parser: "prog"}},
+		{[]string{"prog", "a.txt", "--verbos=1"}, &UnrecognizedArgumentError{Argument: "--verbos=1", Parser: "prog"}},
+		{[]string{"prog", "-x", "a.txt"}, &UnrecognizedArgumentError{Argument: "-x", Parser: "prog"}},
+		{[]string{"prog", "-vx", "a.txt"}, &UnrecognizedArgumentError{Argument: "-vx", Parser: "prog"}},
+	}
+
+	for _, test := range tests {
+		parser, _ := newParser(false)
+		err := parser.Parse(test.args)
+
+		var unrecognized *UnrecognizedArgumentError
+		if !errors.As(err, &unrecognized) {
+			t.Errorf("%v: got error %v, want an UnrecognizedArgumentError", test.args, err)
+		} else if unrecognized.Argument != test.want.Argument || unrecognized.Parser != test.want.Parser {
+			t.Errorf("%v: got %+v, want %+v", test.args, *unrecognized, *test.want)
+		}
+	}
+
+	// unknown flags are values if they are allowed
+	parser, file := newParser(true)
+	err := parser.Parse([]string{"prog", "--verbos"})
+	if err != nil {
+		t.Errorf("unexpected error %s", err)
+	} else if *file != "--verbos" {
+		t.Errorf("got file %s, want --verbos", *file)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		distance int
+	}{
+		{"", "", 0},
+		{"status", "status", 0},
+		{"", "abc", 3},
+		{"statsu", "status", 1},
+		{"--verbos", "--verbose", 1},
+		{"kitten", "sitting", 3},
+		{"ñandú", "nandu", 2},
+	}
+
+	for _, test := range tests {
+		if distance := editDistance(test.a, test.b); distance != test.distance {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", test.a, test.b, distance, test.distance)
+		}
+	}
+}
+
+func TestSuggestions(t *testing.T) {
+	parser, err := NewArgParser("prog", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"--verbose", "--version"} {
+		_, err = parser.AddBool(name, NOSHORTCUT, "", false, ActionStoreTrue, false, false, nil, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"status", "stash"} {
+		_, err = parser.AddSubparser(name, "", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	suggestions := func(args ...string) []string {
+		err := parser.Parse(append([]string{"prog"}, args...))
+
+		var unrecognized *UnrecognizedArgumentError
+		var unknown *UnknownSubcommandError
+		switch {
+			case errors.As(err, &unrecognized):
+				return unrecognized.Suggestions
+			case errors.As(err, &unknown):
+				return unknown.Suggestions
+		}
+		t.Fatalf("%v: got error %v, want an unrecognized argument or unknown subcommand", args, err)
+		return nil
+	}
+
+	if got := suggestions("--verbos", "status"); !reflect.DeepEqual(got, []string{"--verbose"}) {
+		t.Errorf("--verbos: got suggestions %v", got)
+	}
+	if got := suggestions("--verbose=1", "--versoin", "status"); !reflect.DeepEqual(got, []string{"--version"}) {
+		t.Errorf("--versoin: got suggestions %v", got)
+	}
+	if got := suggestions("--quiet", "status"); len(got) != 0 {
+		t.Errorf("--quiet: got suggestions %v, want none", got)
+	}
+	if got := suggestions("STATSU"); !reflect.DeepEqual(got, []string{"status", "stash"}) {
+		t.Errorf("STATSU: got suggestions %v", got)
+	}
+	if got := suggestions("stat"); !reflect.DeepEqual(got, []string{"stash", "status"}) {
+		t.Errorf("stat: got suggestions %v", got)
+	}
+
+	err = parser.Parse([]string{"prog", "--versoe", "status"})
+	if err == nil || !strings.HasSuffix(err.Error(), "did you mean --verbose or --version?") {
+		t.Errorf("got error %v", err)
+	}
+
+	parser.SetSuggestionDistance(0)
+	if got := suggestions("--verbos", "status"); len(got) != 0 {
+		t.Errorf("distance 0: got suggestions %v, want none", got)
+	}
+	err = parser.Parse([]string{"prog", "statsu"})
+	if err == nil || err.Error() != "unknown subcommand statsu" {
+		t.Errorf("distance 0: got error %v", err)
+	}
+}
+
+func TestSuggestionsSubparsers(t *testing.T) {
+	parser, err := NewArgParser("prog", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote, err := parser.AddSubparser("remote", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = remote.Bool("--verbose")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"add", "remove"} {
+		_, err = remote.AddSubparser(name, "", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = parser.Parse([]string{"prog", "remote", "--verbos", "add"})
+	var unrecognized *UnrecognizedArgumentError
+	if !errors.As(err, &unrecognized) || !reflect.DeepEqual(unrecognized.Suggestions, []string{"--verbose"}) {
+		t.Errorf("--verbos: got error %v, want the suggestion --verbose", err)
+	}
+
+	err = parser.Parse([]string{"prog", "remote", "rmeove"})
+	var unknown *UnknownSubcommandError
+	if !errors.As(err, &unknown) || !reflect.DeepEqual(unknown.Suggestions, []string{"remove"}) {
+		t.Errorf("rmeove: got error %v, want the suggestion remove", err)
+	}
+
+	// the distance of the root is set in its subparsers and inherited by
+	// the ones added later
+	parser.SetSuggestionDistance(0)
+	_, err = remote.AddSubparser("prune", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{
+		{"prog", "remote", "--verbos", "add"},
+		{"prog", "remote", "rmeove"},
+		{"prog", "remote", "prnue"},
+	} {
+		err = parser.Parse(args)
+		switch {
+			case errors.As(err, &unrecognized):
+				if len(unrecognized.Suggestions) != 0 {
+					t.Errorf("%v: got suggestions %v, want none", args, unrecognized.Suggestions)
+				}
+			case errors.As(err, &unknown):
+				if len(unknown.Suggestions) != 0 || strings.Contains(err.Error(), "did you mean") {
+					t.Errorf("%v: got suggestions %v in %q, want none", args, unknown.Suggestions, err)
+				}
+			default:
+				t.Errorf("%v: got error %v, want an unrecognized argument or unknown subcommand", args, err)
+		}
+	}
+}
+
+func TestTerminator(t *testing.T) {
+	parser, err := NewArgParser("prog", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verbose, err := parser.AddBool("--verbose", 'v', "", false, ActionStoreTrue, false, false, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := parser.AddString("file", NOSHORTCUT, "", true, ActionStoreValue, "", "", nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		args      []string
+		verbose   bool
+		file      string
+		remainder []string
+	}{
+		{[]string{"prog", "--", "-v"}, false, "-v", []string{}},
+		{[]string{"prog", "-v", "--", "--", "-x", "--verbose"}, true, "--", []string{"-x", "--verbose"}},
+		{[]string{"prog", "a.txt", "--", "-v", "b.txt"}, false, "a.txt", []string{"-v", "b.txt"}},
+		{[]string{"prog", "a.txt", "-v"}, true, "a.txt", nil},
+	}
+
+	for _, step := range steps {
+		err := parser.Parse(step.args)
+		if err != nil {
+			t.Errorf("%v: unexpected error %s", step.args, err)
+			continue
+		}
+		if *verbose != step.verbose || *file != step.file {
+			t.Errorf("%v: got verbose=%t file=%q, want verbose=%t file=%q", step.args, *verbose, *file, step.verbose, step.file)
+		}
+		if remainder := parser.GetRemainder(); !reflect.DeepEqual(remainder, step.remainder) {
+			t.Errorf("%v: got remainder %#v, want %#v", step.args, remainder, step.remainder)
+		}
+	}
+
+	// the terminator does not fill the missing positional
+	err = parser.Parse([]string{"prog", "-v", "--"})
+	var missing *MissingArgumentError
+	if !errors.As(err, &missing) || missing.Argument != "file" {
+		t.Errorf("got error %v, want the missing file", err)
+	}
+}
+
+func TestTerminatorBeforeSubcommand(t *testing.T) {
+	parser, err := NewArgParser("prog", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = parser.AddSubparser("run", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = parser.Parse([]string{"prog", "--", "run", "-x"})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if selected := parser.GetSelectedSubparser(); selected != "" {
+		t.Errorf("subcommand %s selected after --", selected)
+	}
+	if remainder := parser.GetRemainder(); !reflect.DeepEqual(remainder, []string{"run", "-x"}) {
+		t.Errorf("got remainder %v", remainder)
+	}
+}
+
+func TestIsNegativeNumber(t *testing.T) {
+	for value, negative := range map[string]bool{
+		"-5":     true,
+		"-2.5":   true,
+		"-.5":    true,
+		"-1.5e3": true,
+		"-0":     true,
+		"5":      false,
+		"-":      false,
+		"--5":    false,
+		"-e3":    false,
+		"-1x":    false,
+		"-inf":   false,
+		"-v":     false,
+	} {
+		if got := isNegativeNumber(value); got != negative {
+			t.Errorf("isNegativeNumber(%q) = %t, want %t", value, got, negative)
+		}
+	}
+}
+
+func TestNegativeNumbers(t *testing.T) {
+	parser, err := NewArgParser("prog", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offset, err := parser.AddInt("--offset", 'o', "", false, ActionStoreValue, 0, 0, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	scale, err := parser.AddFloat("--scale", 's', "", false, ActionStoreValue, 1, 0, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	delta, err := parser.AddFloat("delta", NOSHORTCUT, "", true, ActionStoreValue, 0, 0, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parse := func(args ...string) {
+		t.Helper()
+		err := parser.Parse(append([]string{"prog"}, args...))
+		if err != nil {
+			t.Errorf("%v: unexpected error %s", args, err)
+		}
+	}
+
+	parse("--offset", "-5", "-2.5")
+	if *offset != -5 || *delta != -2.5 {
+		t.Errorf("got offset=%d delta=%g", *offset, *delta)
+	}
+
+	parse("-.5", "-s", "-1.5e3", "-o-7")
+	if *delta != -.5 || *scale != -1500 || *offset != -7 {
+		t.Errorf("got offset=%d scale=%g delta=%g", *offset, *scale, *delta)
+	}
+
+	parse("--offset=-5", "--scale=-0.25", "0")
+	if *offset != -5 || *scale != -0.25 || *delta != 0 {
+		t.Errorf("got offset=%d scale=%g delta=%g", *offset, *scale, *delta)
+	}
+
+	// not a number, so it is an unknown flag
+	err = parser.Parse([]string{"prog", "-1x"})
+	var unrecognized *UnrecognizedArgumentError
+	if !errors.As(err, &unrecognized) || unrecognized.Argument != "-1x" {
+		t.Errorf("got error %v, want -1x unrecognized", err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	parser, err := NewArgParser("prog", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = parser.AddArgumentsGroup("format", "", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = parser.AddArgumentsGroup("target", "", true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"--json", "--yaml"} {
+		_, err = parser.AddBool(name, rune(name[2]), "", false, ActionStoreTrue, false, false, nil, "format")
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = parser.AddString("--host", 'H', "", false, ActionStoreValue, "", "", nil, "target")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = parser.AddString("--socket", NOSHORTCUT, "", false, ActionStoreValue, "", "", nil, "target")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = parser.AddInt("--count", 'c', "", true, ActionStoreValue, 0, 0, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("exclusive", func(t *testing.T) {
+		err := parser.Parse([]string{"prog", "-c", "1", "-H", "a", "--json", "-y"})
+
+		var exclusive *ExclusiveGroupError
+		if !errors.As(err, &exclusive) {
+			t.Fatalf("got error %v, want an ExclusiveGroupError", err)
+		}
+		sort.Strings(exclusive.Arguments)
+		if exclusive.Group != "format" || !reflect.DeepEqual(exclusive.Arguments, []string{"--json[-j]", "--yaml[-y]"}) {
+			t.Errorf("got %+v", *exclusive)
+		}
+	})
+
+	t.Run("required and missing together", func(t *testing.T) {
+		err := parser.Parse([]string{"prog", "--json"})
+
+		var required *RequiredGroupError
+		if !errors.As(err, &required) {
+			t.Fatalf("got error %v, want a RequiredGroupError", err)
+		}
+		if required.Group != "target" || !reflect.DeepEqual(required.Arguments, []string{"--host[-H]", "--socket"}) {
+			t.Errorf("got %+v", *required)
+		}
+
+		var missing *MissingArgumentError
+		if !errors.As(err, &missing) || missing.Argument != "--count" || missing.Shortcut != 'c' {
+			t.Errorf("got error %v, want --count missing", err)
+		}
+		if !strings.Contains(err.Error(), "argument --count[-c] has no value\n") {
+			t.Errorf("got message %q", err)
+		}
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		err := parser.Parse([]string{"prog", "--socket", "s", "-c", "ten"})
+
+		var invalid *InvalidValueError
+		if !errors.As(err, &invalid) {
+			t.Fatalf("got error %v, want an InvalidValueError", err)
+		}
+		want := InvalidValueError{Value: "ten", Argument: "--count", Shortcut: 'c', Parser: "prog", Reason: "must be an integer"}
+		if *invalid != want {
+			t.Errorf("got %+v, want %+v", *invalid, want)
+		}
+		if err.Error() != `Invalid value "ten" for argument --count[-c], must be an integer` {
+			t.Errorf("got message %q", err)
+		}
+	})
+
+	t.Run("missing value", func(t *testing.T) {
+		err := parser.Parse([]string{"prog", "--socket", "s", "--count"})
+
+		var missing *MissingValueError
+		if !errors.As(err, &missing) || missing.Argument != "--count" {
+			t.Errorf("got error %v, want a MissingValueError of --count", err)
+		}
+	})
+
+	t.Run("help", func(t *testing.T) {
+		err := parser.Parse([]string{"prog", "-h"})
+		if !errors.Is(err, ErrHelp) {
+			t.Errorf("got error %v, want ErrHelp", err)
+		}
+	})
+}
+
+func TestCommandError(t *testing.T) {
+	parser, err := NewArgParser("prog", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote, err := parser.AddSubparser("remote", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote.SetSubparserRequired(true)
+	add, err := remote.AddSubparser("add", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = add.AddString("url", NOSHORTCUT, "", true, ActionStoreValue, "", "", nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = add.AddString("--name", 'n', "", false, ActionStoreValue, "", "", nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// cause returns the error wrapped by the CommandError of err
+	cause := func(args []string, path string) error {
+		t.Helper()
+		err := parser.Parse(args)
+
+		var cmdErr *CommandError
+		if !errors.As(err, &cmdErr) {
+			t.Fatalf("%v: got error %v, want a CommandError", args, err)
+		}
+		if cmdErr.Path != path {
+			t.Errorf("%v: got path %q, want %q", args, cmdErr.Path, path)
+		}
+		if errors.As(cmdErr.Err, new(*CommandError)) {
+			t.Errorf("%v: CommandError wraps another CommandError", args)
+		}
+		return cmdErr.Err
+	}
+
+	err = cause([]string{"prog", "remote"}, "prog remote")
+	var noSubcommand *MissingSubcommandError
+	if !errors.As(err, &noSubcommand) || !reflect.DeepEqual(noSubcommand.Subcommands, []string{"add"}) {
+		t.Errorf("got %v, want a MissingSubcommandError", err)
+	}
+
+	err = cause([]string{"prog", "remote", "ad"}, "prog remote")
+	var unknown *UnknownSubcommandError
+	if !errors.As(err, &unknown) || unknown.Subcommand != "ad" || unknown.Parser != "prog remote" {
+		t.Errorf("got %v, want an UnknownSubcommandError", err)
+	}
+
+	err = cause([]string{"prog", "remote", "add", "--name", "origin"}, "prog remote add")
+	var missing *MissingArgumentError
+	if !errors.As(err, &missing) || missing.Argument != "url" || missing.Parser != "prog remote add" {
+		t.Errorf("got %v, want a MissingArgumentError", err)
+	}
+
+	err = cause([]string{"prog", "remote", "add", "https://example.com", "-n"}, "prog remote add")
+	var noValue *MissingValueError
+	if !errors.As(err, &noValue) || noValue.Shortcut != 'n' {
+		t.Errorf("got %v, want a MissingValueError", err)
+	}
+
+	err = cause([]string{"prog", "remote", "add", "--nmae", "x", "https://example.com"}, "prog remote add")
+	var unrecognized *UnrecognizedArgumentError
+	if !errors.As(err, &unrecognized) || !reflect.DeepEqual(unrecognized.Suggestions, []string{"--name"}) {
+		t.Errorf("got %v, want an UnrecognizedArgumentError", err)
+	}
+	if want := "prog remote add: unrecognized argument --nmae, did you mean --name?"; parser.Parse([]string{"prog", "remote", "add", "--nmae"}).Error() != want {
+		t.Errorf("want message %q", want)
+	}
+
+	// errors of the root parser are not wrapped
+	err = parser.Parse([]string{"prog", "--nmae"})
+	if errors.As(err, new(*CommandError)) {
+		t.Errorf("got %v, want an unwrapped error", err)
+	}
+
+	if usage := add.Usage(); !strings.HasPrefix(usage, "Usage: prog remote add ") {
+		t.Errorf("got usage %q", usage)
+	}
+}
+
+func TestIntrospection(t *testing.T) {
+	parser, err := NewArgParser("tool", "manages things", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	format, err := parser.AddArgumentsGroup("format", "output format", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = format.AddStringChoices("--output", 'o', "output as", false, ActionStoreValue, "json", "", []string{"json", "yaml"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = format.AddBool("--raw", NOSHORTCUT, "", false, ActionStoreTrue, false, false, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = parser.AddInt("--retries", 'r', "", true, ActionStoreValue, 3, 0, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = parser.AddString("file", NOSHORTCUT, "input file", true, ActionStoreValue, "", "", nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = parser.AddString("dir", NOSHORTCUT, "", true, ActionStoreValue, "", "", nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"status", "remote"} {
+		_, err = parser.AddSubparser(name, name+" help", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	remote := parser.Subparsers()[0]
+	add, err := remote.AddSubparser("add", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := func(args []*Argument) []string {
+		list := []string{}
+		for _, arg := range args {
+			list = append(list, arg.Name())
+		}
+		return list
+	}
+
+	if got := names(parser.Arguments()); !reflect.DeepEqual(got, []string{"--help", "--output", "--raw", "--retries"}) {
+		t.Errorf("got arguments %v", got)
+	}
+	if got := names(parser.Positionals()); !reflect.DeepEqual(got, []string{"file", "dir"}) {
+		t.Errorf("got positionals %v", got)
+	}
+	if len(add.Arguments()) != 0 {
+		t.Errorf("add has arguments %v", names(add.Arguments()))
+	}
+
+	output, ok := parser.Argument("--output")
+	if !ok {
+		t.Fatal("--output not found")
+	}
+	if output.Shortcut() != 'o' || output.Description() != "output as" || output.Action() != ActionStoreValue {
+		t.Errorf("got --output %+v", *output)
+	}
+	if output.Default() != "json" || !reflect.DeepEqual(output.Choices(), []string{"json", "yaml"}) {
+		t.Errorf("got default %q and choices %v", output.Default(), output.Choices())
+	}
+	retries, _ := parser.Argument("--retries")
+	if !retries.Mandatory() || retries.Positional() || retries.Choices() != nil || retries.Default() != "3" {
+		t.Errorf("got --retries %+v", *retries)
+	}
+	if file, _ := parser.Argument("file"); !file.Positional() {
+		t.Errorf("file is not positional")
+	}
+	if _, ok := parser.Argument("--missing"); ok {
+		t.Errorf("found --missing")
+	}
+
+	groups := parser.Groups()
+	if len(groups) != 1 || groups[0].Name() != "format" || groups[0].Description() != "output format" {
+		t.Fatalf("got groups %v", groups)
+	}
+	if !groups[0].Exclusive() || groups[0].Required() {
+		t.Errorf("got exclusive=%t required=%t", groups[0].Exclusive(), groups[0].Required())
+	}
+	if got := names(groups[0].Arguments()); !reflect.DeepEqual(got, []string{"--output", "--raw"}) {
+		t.Errorf("got group arguments %v", got)
+	}
+
+	subparsers := parser.Subparsers()
+	if len(subparsers) != 2 || subparsers[0].Name() != "remote" || subparsers[1].Name() != "status" {
+		t.Fatalf("got subparsers %v", subparsers)
+	}
+	if subparsers[1].Description() != "status help" {
+		t.Errorf("got description %q", subparsers[1].Description())
+	}
+	if add.Path() != "tool remote add" || add.Parent() != remote || parser.Parent() != nil {
+		t.Errorf("got path %q", add.Path())
+	}
+
+	// the slices are copies
+	parser.Positionals()[0] = nil
+	groups[0].Arguments()[0] = nil
+	if parser.Positionals()[0] == nil || groups[0].Arguments()[0] == nil {
+		t.Errorf("the parser was modified through a returned slice")
+	}
+}
+
+func TestEnvironment(t *testing.T) {
+	parser, err := NewArgParser("prog", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.SetEnvPrefix("app")
+	maxSize, err := parser.AddInt("--max-size", 's', "maximum size", true, ActionStoreValue, 0, 0, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	color, err := parser.AddBool("--no-color", NOSHORTCUT, "", false, ActionStoreFalse, true, false, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := parser.AddString("--token", NOSHORTCUT, "api token", false, ActionStoreValue, "", "", nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = parser.SetEnv("--token", "API_TOKEN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = parser.AddString("file", NOSHORTCUT, "", false, ActionStoreValue, "", "", nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("APP_MAX_SIZE", "10")
+	t.Setenv("APP_NO_COLOR", "true")
+	t.Setenv("API_TOKEN", "secret")
+	t.Setenv("APP_TOKEN", "ignored")
+
+	err = parser.Parse([]string{"prog", "a.txt"})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if *maxSize != 10 || *color || *token != "secret" {
+		t.Errorf("got max-size=%d color=%t token=%s", *maxSize, *color, *token)
+	}
+
+	// the command line wins
+	err = parser.Parse([]string{"prog", "-s", "3", "--token", "cli", "a.txt"})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if *maxSize != 3 || *token != "cli" {
+		t.Errorf("got max-size=%d token=%s", *maxSize, *token)
+	}
+
+	// false does not give the flag
+	t.Setenv("APP_NO_COLOR", "0")
+	err = parser.Parse([]string{"prog", "a.txt"})
+	if err != nil || !*color {
+		t.Errorf("got error %v and color=%t", err, *color)
+	}
+
+	t.Setenv("APP_NO_COLOR", "maybe")
+	err = parser.Parse([]string{"prog", "a.txt"})
+	var invalid *InvalidValueError
+	if !errors.As(err, &invalid) || !strings.HasPrefix(err.Error(), "environment variable APP_NO_COLOR: ") {
+		t.Errorf("got error %v", err)
+	}
+
+	help := parser.Help()
+	for _, line := range []string{"maximum size [env: APP_MAX_SIZE]\n", "api token [env: API_TOKEN]\n"} {
+		if !strings.Contains(help, line) {
+			t.Errorf("%q is not in the help:\n%s", line, help)
+		}
+	}
+	if file, _ := parser.Argument("file"); file.Env() != "" {
+		t.Errorf("positional file has env %s", file.Env())
+	}
+	if help, _ := parser.Argument("--help"); help.Env() != "" {
+		t.Errorf("--help has env %s", help.Env())
+	}
+
+	if err := parser.SetEnv("file", "FILE"); err == nil {
+		t.Errorf("expected an error for a positional")
+	}
+	if err := parser.SetEnv("--missing", "MISSING"); err == nil {
+		t.Errorf("expected an error for an undefined argument")
+	}
+}
+
+func TestEnvironmentExclusiveGroup(t *testing.T) {
+	parser, err := NewArgParser("prog", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.SetEnvPrefix("APP")
+	_, err = parser.AddArgumentsGroup("format", "", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	json, err := parser.AddBool("--json", NOSHORTCUT, "", false, ActionStoreTrue, false, false, nil, "format")
+	if err != nil {
+		t.Fatal(err)
+	}
+	yaml, err := parser.AddBool("--yaml", NOSHORTCUT, "", false, ActionStoreTrue, false, false, nil, "format")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a flag given in the command line hides the environment of the group
+	t.Setenv("APP_JSON", "1")
+	err = parser.Parse([]string{"prog", "--yaml"})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if *json || !*yaml {
+		t.Errorf("got json=%t yaml=%t, want only yaml", *json, *yaml)
+	}
+
+	// but two flags of the group in the environment are exclusive
+	t.Setenv("APP_YAML", "true")
+	err = parser.Parse([]string{"prog"})
+	var exclusive *ExclusiveGroupError
+	if !errors.As(err, &exclusive) {
+		t.Fatalf("got error %v, want an ExclusiveGroupError", err)
+	}
+	if exclusive.Group != "format" || !reflect.DeepEqual(exclusive.Arguments, []string{"--json", "--yaml"}) {
+		t.Errorf("got group %s and arguments %v", exclusive.Group, exclusive.Arguments)
+	}
+
+	// a false flag is not given
+	t.Setenv("APP_JSON", "false")
+	err = parser.Parse([]string{"prog"})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if *json || !*yaml || parser.Source("--json") != SourceDefault {
+		t.Errorf("got json=%t yaml=%t and json from %s, want only yaml", *json, *yaml, parser.Source("--json"))
+	}
+}
+
+func TestEnvironmentExclusiveValues(t *testing.T) {
+	parser, err := NewArgParser("prog", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.SetEnvPrefix("APP")
+	output, err := parser.AddArgumentsGroup("output", "", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = output.String("--file", Short('f'))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = output.String("--url", Env("UPLOAD_URL"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("APP_FILE", "out.txt")
+	t.Setenv("UPLOAD_URL", "https://example.com")
+
+	err = parser.Parse([]string{"prog"})
+	var exclusive *ExclusiveGroupError
+	if !errors.As(err, &exclusive) || exclusive.Group != "output" {
+		t.Fatalf("got error %v, want an ExclusiveGroupError of output", err)
+	}
+	if want := `more than one argument of group "output" was specified (--file[-f],--url)`; err.Error() != want {
+		t.Errorf("got error %q, want %q", err, want)
+	}
+
+	// one of them in the command line wins over both
+	err = parser.Parse([]string{"prog", "--url", "https://example.org"})
+	if err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+	if parser.Source("--url") != SourceCommandLine || parser.IsSet("--file") {
+		t.Errorf("got sources url=%s file=%s", parser.Source("--url"), parser.Source("--file"))
+	}
+}
+
+func TestEnvironmentOptions(t *testing.T) {
+	t.Setenv("PORT", "8080")
+	t.Setenv("LEVEL", "debug")
+
+	parser, err := NewArgParser("prog", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, err := parser.Int("--port", Env("PORT"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var opts struct {
+		Level string `env:"LEVEL"`
+	}
+	err = parser.AddStruct(&opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = parser.Parse([]string{"prog"})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if *port != 8080 || opts.Level != "debug" {
+		t.Errorf("got port=%d level=%s", *port, opts.Level)
+	}
+}
+
+func TestFromfile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, content string) string {
+		path := filepath.Join(dir, name)
+		err := os.WriteFile(path, []byte(content), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	common := write("common.args", "--level 2\r\n\n--name 'Ada Lovelace'\n")
+	args := write("build.args", "--tag x\n@"+common+"\n--tag y\n")
+	lines := write("lines.args", "--name\nGrace Hopper\n")
+
+	parser, err := NewArgParser("prog", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.SetFromfilePrefixChars("@+")
+	level, _ := parser.Int("--level")
+	name, _ := parser.String("--name")
+	tags, err := parser.StringSlice("--tag")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// nested files are expanded in place
+	err = parser.Parse([]string{"prog", "--tag", "w", "@" + args, "--level", "3"})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if *level != 3 || *name != "Ada Lovelace" || !reflect.DeepEqual(*tags, []string{"w", "x", "y"}) {
+		t.Errorf("got level=%d name=%q tags=%v", *level, *name, *tags)
+	}
+
+	// any of the prefix chars, and a lone prefix is a value
+	err = parser.Parse([]string{"prog", "+" + common, "--tag", "@"})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if *level != 2 || !reflect.DeepEqual(*tags, []string{"@"}) {
+		t.Errorf("got level=%d tags=%v", *level, *tags)
+	}
+
+	parser.SetFromfileOnePerLine(true)
+	err = parser.Parse([]string{"prog", "@" + lines})
+	if err != nil || *name != "Grace Hopper" {
+		t.Errorf("got error %v and name %q", err, *name)
+	}
+	parser.SetFromfileOnePerLine(false)
+
+	// not after the terminator
+	err = parser.Parse([]string{"prog", "--", "@" + args})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if remainder := parser.GetRemainder(); !reflect.DeepEqual(remainder, []string{"@" + args}) {
+		t.Errorf("got remainder %v", remainder)
+	}
+
+	missing := filepath.Join(dir, "missing.args")
+	err = parser.Parse([]string{"prog", "@" + missing})
+	if !errors.Is(err, os.ErrNotExist) || !strings.HasPrefix(err.Error(), "argument file "+missing+": ") {
+		t.Errorf("got error %v, want a missing file", err)
+	}
+
+	parser.SetFromfilePrefixChars("")
+	err = parser.Parse([]string{"prog", "--name", "@" + missing})
+	if err != nil || *name != "@"+missing {
+		t.Errorf("got error %v and name %q with fromfiles disabled", err, *name)
+	}
+}
+
+func TestFromfileCycle(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.args")
+	second := filepath.Join(dir, "second.args")
+	self := filepath.Join(dir, "self.args")
+	level := filepath.Join(dir, "level.args")
+	for path, content := range map[string]string{
+		first:  "--level 1 @" + second,
+		second: "@" + first,
+		self:   "@" + self,
+		level:  "--level 2",
+	} {
+		err := os.WriteFile(path, []byte(content), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	parser, err := NewArgParser("prog", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.SetFromfilePrefixChars("@")
+	_, err = parser.Int("--level")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = parser.Parse([]string{"prog", "@" + first})
+	if err == nil || err.Error() != "argument file "+first+" includes itself" {
+		t.Errorf("got error %v", err)
+	}
+	err = parser.Parse([]string{"prog", "@" + self})
+	if err == nil || err.Error() != "argument file "+self+" includes itself" {
+		t.Errorf("got error %v", err)
+	}
+
+	// the same file twice is not a cycle
+	err = parser.Parse([]string{"prog", "@" + level, "@" + level})
+	if err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+}
+
+func TestSource(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "prog.ini")
+	err := os.WriteFile(config, []byte("host = config\nport = 1\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PROG_PORT", "2")
+
+	parser, err := NewArgParser("prog", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.SetEnvPrefix("prog")
+	parser.SetConfigFile(config)
+	_, _ = parser.String("--host")
+	_, _ = parser.Int("--port", Short('p'))
+	_, _ = parser.Int("--level", Action(ActionStoreConst), Const(3))
+	_, _ = parser.Bool("--verbose", Short('v'))
+	_, err = parser.String("file")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = parser.Parse([]string{"prog", "--level", "a.txt"})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	for name, source := range map[string]Source{
+		"--host":    SourceConfigFile,
+		"host":      SourceConfigFile,
+		"--port":    SourceEnvironment,
+		"-p":        SourceEnvironment,
+		"p":         SourceEnvironment,
+		"--level":   SourceConst,
+		"file":      SourceCommandLine,
+		"--verbose": SourceDefault,
+		"--missing": SourceDefault,
+	} {
+		if got := parser.Source(name); got != source {
+			t.Errorf("Source(%q) = %s, want %s", name, got, source)
+		}
+	}
+	if parser.IsSet("-v") || !parser.IsSet("--level") {
+		t.Errorf("got IsSet(-v)=%t IsSet(--level)=%t", parser.IsSet("-v"), parser.IsSet("--level"))
+	}
+
+	result, err := parser.ParseArgs([]string{"prog", "-v", "-p", "5", "b.txt"})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if result.Source("v") != SourceCommandLine || result.Source("--port") != SourceCommandLine || result.Source("host") != SourceConfigFile {
+		t.Errorf("got sources verbose=%s port=%s host=%s", result.Source("v"), result.Source("--port"), result.Source("host"))
+	}
+	if result.IsSet("--level") {
+		t.Errorf("--level is set in the result")
+	}
+	// ParseArgs does not change the sources of the parser
+	if parser.Source("--port") != SourceEnvironment {
+		t.Errorf("got source of --port %s after ParseArgs", parser.Source("--port"))
+	}
+
+	if SourceConfigFile.String() != "config file" || Source(9).String() != "Source(9)" {
+		t.Errorf("got %s and %s", SourceConfigFile, Source(9))
+	}
+}
+
+func TestSourceSubparsers(t *testing.T) {
+	parser, err := NewArgParser("prog", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := parser.AddSubparser("a", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = a.String("--name")
+	nested, err := a.AddSubparser("nested", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = nested.Bool("--deep")
+	b, err := parser.AddSubparser("b", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = b.String("--name")
+
+	err = parser.Parse([]string{"prog", "a", "--name", "x", "nested", "--deep"})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if !a.IsSet("--name") || !nested.IsSet("--deep") || a.GetSelectedSubparser() != "nested" {
+		t.Fatalf("got a --name set=%t, nested --deep set=%t, selected %q", a.IsSet("--name"), nested.IsSet("--deep"), a.GetSelectedSubparser())
+	}
+
+	// a is not selected now, it does not keep the sources of the first Parse
+	err = parser.Parse([]string{"prog", "b", "--name", "y"})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if !b.IsSet("--name") {
+		t.Errorf("b --name is not set")
+	}
+	if a.IsSet("--name") || a.Source("--name") != SourceDefault {
+		t.Errorf("a --name keeps the source %s of the previous Parse", a.Source("--name"))
+	}
+	if nested.IsSet("--deep") {
+		t.Errorf("nested --deep keeps the source of the previous Parse")
+	}
+	if selected := a.GetSelectedSubparser(); selected != "" {
+		t.Errorf("a keeps the selected subparser %q of the previous Parse", selected)
+	}
+}
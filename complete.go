@@ -0,0 +1,210 @@
+package argparse
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// CompletionFunc returns the candidates to complete the value of an
+// argument, Ex: the names of the branches. toComplete is the partial value
+// being completed, candidates that do not begin with it are discarded.
+type CompletionFunc func(toComplete string) []string
+
+// SetCompletionCommand enables the completion of values at runtime, the
+// completion scripts call the program with the hidden subcommand name
+// followed by the words of the command line, Ex: with __complete the
+// scripts run tool __complete remote add --branch ma. Parse prints the
+// candidates and returns ErrCompletion in those calls. Empty name, the
+// default, disables it, the scripts only complete the choices then.
+func (parser *ArgParser) SetCompletionCommand(name string) error {
+	if parser.parent != nil {
+		return fmt.Errorf("Completion command can only be set in the root parser, not in %s", parser.path())
+	}
+	if name != "" && parser.existsSubparser(name) {
+		return fmt.Errorf("Completion command %s is already a subparser of %s", name, parser.name)
+	}
+	parser.completionCommand = name
+	return nil
+}
+
+// SetCompletionFunc sets the function that completes the value of the
+// argument at runtime, it is called by the completion scripts through the
+// command set by SetCompletionCommand. Parse returns ErrCompletion in those
+// calls, the program must exit then, see Parse.
+func (parser *ArgParser) SetCompletionFunc(name string, fn CompletionFunc) error {
+	arg, exists := parser.getArgument(name)
+	if !exists {
+		return fmt.Errorf("Argument %s is not defined in parser %s", name, parser.name)
+	}
+	if !arg.takesValue() {
+		return fmt.Errorf("Argument %s does not take a value", arg.name)
+	}
+	arg.completion = fn
+	return nil
+}
+
+// hasCompletionFuncs checks if the completion scripts of the parser call it
+// to complete values at runtime: the completion command is set and the
+// parser or its subparsers have arguments with completion functions
+func (parser *ArgParser) hasCompletionFuncs() bool {
+	if parser.completionCommand == "" {
+		return false
+	}
+	for _, p := range parser.allParsers() {
+		for _, arg := range p.arguments {
+			if arg.completion != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// completeValue returns the candidates for the value of the argument, from
+// its completion function or its choices
+func (arg Argument) completeValue(toComplete string) []string {
+	candidates := arg.val.getChoices()
+	if arg.completion != nil {
+		candidates = arg.completion(toComplete)
+	}
+	return filterPrefix(candidates, toComplete)
+}
+
+func filterPrefix(candidates []string, prefix string) []string {
+	filtered := []string{}
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, prefix) {
+			filtered = append(filtered, candidate)
+		}
+	}
+	return filtered
+}
+
+// completionContext follows the words as Parse does and returns the parser
+// selected by them, the argument that expects the next word as its value,
+// if any, the number of positional arguments given and if -- was found
+func (parser *ArgParser) completionContext(words []string) (*ArgParser, *Argument, int, bool) {
+	current := parser
+	var expecting *Argument
+	positionalIndex := 0
+	terminated := false
+
+	for _, word := range words {
+		if expecting != nil {
+			expecting = nil
+			continue
+		}
+
+		if terminated {
+			positionalIndex++
+			continue
+		}
+
+		switch current.getArgumentCategory(word) {
+			case NameCategory, NameEqCategory:
+				arg, _ := current.getArgument(strings.TrimSuffix(word, "="))
+				if arg.takesValue() {
+					expecting = arg
+				}
+
+			case ShortcutCategory, ShortcutEqCategory:
+				arg, _ := current.getArgumentFromShortcut(rune(word[1]))
+				if arg.takesValue() {
+					expecting = arg
+				}
+
+			case ShortcutGroupCategory, ShortcutGroupEqCategory:
+				shortcuts := strings.TrimSuffix(word[1:], "=")
+				for i, char := range shortcuts {
+					arg, _ := current.getArgumentFromShortcut(char)
+					if arg.takesValue() {
+						// the rest of the word is the value, Ex: -xzVALUE
+						if i == len(shortcuts) - 1 {
+							expecting = arg
+						}
+						break
+					}
+				}
+
+			case TerminatorCategory:
+				terminated = true
+
+			case ValueCategory:
+				if positionalIndex < len(current.posArguments) {
+					positionalIndex++
+				} else if subparser, ok := current.getSubparser(word); ok {
+					current = subparser
+					positionalIndex = 0
+				}
+		}
+	}
+
+	return current, expecting, positionalIndex, terminated
+}
+
+// Complete returns the candidates to complete the last of the arguments,
+// the arguments do not include the name of the program, Ex: for
+// "tool remote add --bra" they are remote, add and --bra. Values are
+// completed from the completion functions or the choices of the arguments.
+func (parser *ArgParser) Complete(arguments []string) []string {
+	if len(arguments) == 0 {
+		arguments = []string{""}
+	}
+	toComplete := arguments[len(arguments)-1]
+
+	current, expecting, positionalIndex, terminated := parser.completionContext(arguments[:len(arguments)-1])
+
+	if expecting != nil {
+		return expecting.completeValue(toComplete)
+	}
+
+	if !terminated && strings.HasPrefix(toComplete, string(pARAMPREFIX)) {
+		switch current.getArgumentCategory(toComplete) {
+			case NameValueCategory, NameEqCategory:
+				// --name=value
+				groups := strings.SplitN(toComplete, "=", 2)
+				arg, _ := current.getArgument(groups[0])
+				candidates := []string{}
+				for _, candidate := range arg.completeValue(groups[1]) {
+					candidates = append(candidates, groups[0] + "=" + candidate)
+				}
+				return candidates
+		}
+
+		names := []string{}
+		for _, arg := range current.Arguments() {
+			names = append(names, arg.flagNames()...)
+		}
+		sort.Strings(names)
+		return filterPrefix(names, toComplete)
+	}
+
+	if positionalIndex < len(current.posArguments) {
+		return current.posArguments[positionalIndex].completeValue(toComplete)
+	}
+
+	if terminated {
+		return []string{}
+	}
+	return filterPrefix(current.subparserNames(), toComplete)
+}
+
+// isCompletion checks if the arguments are a call of a completion script,
+// Ex: tool __complete remote --bra
+func (parser *ArgParser) isCompletion(arguments []string) bool {
+	return parser.parent == nil && parser.completionCommand != "" && len(arguments) > 1 && arguments[1] == parser.completionCommand
+}
+
+// printCompletion prints the candidates for a call of a completion script
+// to the standard output, one per line
+func (parser *ArgParser) printCompletion(arguments []string) error {
+	for _, candidate := range parser.Complete(arguments[2:]) {
+		_, err := fmt.Fprintln(os.Stdout, candidate)
+		if err != nil {
+			return err
+		}
+	}
+	return ErrCompletion
+}
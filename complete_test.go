@@ -0,0 +1,227 @@
+package argparse
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func branches(toComplete string) []string {
+	return []string{"main", "master", "dev"}
+}
+
+func TestComplete(t *testing.T) {
+	parser := newToolParser(t)
+	add := parser.subparsers["remote"].subparsers["add"]
+	_, err := add.String("--branch", Short('b'), Completion(branches))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"", []string{"remote", "status"}},
+		{"s", []string{"status"}},
+		{"--", []string{"--help", "--output", "--raw", "--token", "--verbose"}},
+		{"-o ", []string{"json", "text", "yaml"}},
+		{"--output=y", []string{"--output=yaml"}},
+		{"-vo t", []string{"text"}},
+		{"remote --timeout 3 ", []string{"add"}},
+		{"remote add --b", []string{"--branch"}},
+		{"remote add --branch ma", []string{"main", "master"}},
+		{"remote add -b d", []string{"dev"}},
+		{"remote add origin ", []string{"git", "hg"}},
+		{"remote add origin hg ", []string{}},
+		{"remote add -- --", []string{}},
+		{"status -- ", []string{}},
+	}
+
+	for _, test := range tests {
+		args := strings.Split(test.line, " ")
+		if got := parser.Complete(args); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: got %q, want %q", test.line, got, test.want)
+		}
+	}
+
+	if got := parser.Complete(nil); !reflect.DeepEqual(got, []string{"remote", "status"}) {
+		t.Errorf("no arguments: got %q", got)
+	}
+}
+
+func TestSetCompletionFunc(t *testing.T) {
+	parser := newToolParser(t)
+
+	if err := parser.SetCompletionFunc("--missing", branches); err == nil {
+		t.Errorf("expected an error for an undefined argument")
+	}
+	if err := parser.SetCompletionFunc("--verbose", branches); err == nil {
+		t.Errorf("expected an error for a flag without value")
+	}
+	if err := parser.SetCompletionFunc("--token", branches); err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+	if got := parser.Complete([]string{"--token", "d"}); !reflect.DeepEqual(got, []string{"dev"}) {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestParseCompletion(t *testing.T) {
+	parser := newToolParser(t)
+	err := parser.subparsers["remote"].subparsers["add"].SetCompletionFunc("name", branches)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// parse prints the output of Parse to the standard output
+	parse := func(args ...string) (string, error) {
+		reader, writer, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		stdout := os.Stdout
+		os.Stdout = writer
+		err = parser.Parse(args)
+		os.Stdout = stdout
+		writer.Close()
+
+		output, _ := io.ReadAll(reader)
+		return string(output), err
+	}
+
+	// runtime completion is disabled by default
+	output, err := parse("tool", "__complete", "remote", "add", "ma")
+	var unknown *UnknownSubcommandError
+	if !errors.As(err, &unknown) || output != "" {
+		t.Errorf("got error %v and output %q, want __complete to be an unknown subcommand", err, output)
+	}
+
+	err = parser.SetCompletionCommand("__complete")
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err = parse("tool", "__complete", "remote", "add", "ma")
+	if !errors.Is(err, ErrCompletion) {
+		t.Errorf("got error %v, want ErrCompletion", err)
+	}
+	if output != "main\nmaster\n" {
+		t.Errorf("got output %q", output)
+	}
+
+	// only the root parser answers completions
+	err = parser.Parse([]string{"tool", "remote", "__complete"})
+	if !errors.As(err, &unknown) {
+		t.Errorf("got error %v, want __complete to be an unknown subcommand", err)
+	}
+}
+
+func TestSetCompletionCommand(t *testing.T) {
+	parser := newToolParser(t)
+
+	err := parser.subparsers["remote"].SetCompletionCommand("__complete")
+	if err == nil || err.Error() != "Completion command can only be set in the root parser, not in tool remote" {
+		t.Errorf("got error %v for a subparser", err)
+	}
+	err = parser.SetCompletionCommand("status")
+	if err == nil || err.Error() != "Completion command status is already a subparser of tool" {
+		t.Errorf("got error %v for the name of a subparser", err)
+	}
+
+	err = parser.SetCompletionCommand("complete")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = parser.AddSubparser("complete", "", true)
+	if err == nil {
+		t.Errorf("expected an error for a subparser named as the completion command")
+	}
+	if !parser.isCompletion([]string{"tool", "complete", "st"}) || parser.isCompletion([]string{"tool", "__complete", "st"}) {
+		t.Errorf("the completion command is not complete")
+	}
+
+	err = parser.SetCompletionCommand("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parser.isCompletion([]string{"tool", "complete", "st"}) {
+		t.Errorf("the completion command is not disabled")
+	}
+}
+
+func TestDynamicCompletionScripts(t *testing.T) {
+	parser := newToolParser(t)
+	err := parser.subparsers["remote"].subparsers["add"].SetCompletionFunc("name", branches)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = parser.SetCompletionFunc("--token", branches)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scripts := map[string]func(io.Writer) error{
+		"tool.bash": parser.WriteBashCompletion,
+		"tool.zsh":  parser.WriteZshCompletion,
+		"tool.fish": parser.WriteFishCompletion,
+	}
+
+	// without the completion command the scripts only complete choices
+	for name, write := range scripts {
+		var script bytes.Buffer
+		if err := write(&script); err != nil {
+			t.Fatal(err)
+		}
+		checkGolden(t, name, script.Bytes())
+	}
+
+	err = parser.SetCompletionCommand("__complete")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, write := range scripts {
+		var script bytes.Buffer
+		if err := write(&script); err != nil {
+			t.Fatal(err)
+		}
+		checkGolden(t, strings.Replace(name, "tool", "tool_dynamic", 1), script.Bytes())
+	}
+
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	// a tool function stands for the program and prints its arguments
+	var script bytes.Buffer
+	_ = parser.WriteBashCompletion(&script)
+	for line, want := range map[string]string{
+		"tool --token=":       "__complete --token ",
+		"tool remote add m":   "__complete remote add m",
+		"tool -v remote add ": "__complete -v remote add ",
+	} {
+		words := strings.Split(line, " ")
+		if strings.HasSuffix(line, "=") {
+			words = append(strings.Split(strings.TrimSuffix(line, "="), " "), "=", "")
+		}
+		quoted := make([]string, 0, len(words))
+		for _, word := range words {
+			quoted = append(quoted, shellQuote(word))
+		}
+		run := "tool() { echo \"$*\"; }\n" + script.String() +
+			"\nCOMP_WORDS=(" + strings.Join(quoted, " ") + ")\nCOMP_CWORD=$((${#COMP_WORDS[@]} - 1))\n" +
+			"_tool_completion\necho \"${COMPREPLY[*]}\"\n"
+
+		out, err := exec.Command(bash, "-c", run).CombinedOutput()
+		if err != nil {
+			t.Errorf("%q: %s\n%s", line, err, out)
+		} else if got := strings.TrimRight(string(out), "\n"); got != want {
+			t.Errorf("%q: got call %q, want %q", line, got, want)
+		}
+	}
+}
@@ -0,0 +1,512 @@
+package argparse
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// allParsers returns the parser and its subparsers recursively, sorted by
+// name in each level
+func (parser *ArgParser) allParsers() []*ArgParser {
+	parsers := []*ArgParser{parser}
+	for _, subparser := range parser.Subparsers() {
+		parsers = append(parsers, subparser.allParsers()...)
+	}
+	return parsers
+}
+
+// flagNames returns the names and shortcuts of the arguments that are not
+// positional, Ex: --verbose -v
+func (parser *ArgParser) flagNames(onlyValues bool) []string {
+	names := []string{}
+	for _, arg := range parser.Arguments() {
+		if onlyValues && !arg.takesValue() {
+			continue
+		}
+		names = append(names, arg.flagNames()...)
+	}
+	return names
+}
+
+// flagNames returns the name and the shortcut of the argument as they are
+// written in the command line
+func (arg Argument) flagNames() []string {
+	names := []string{}
+	if arg.name != "" {
+		names = append(names, arg.name)
+	}
+	if arg.shortcut != NOSHORTCUT {
+		names = append(names, string(pARAMPREFIX) + string(arg.shortcut))
+	}
+	return names
+}
+
+// shellQuote quotes a word for sh, Ex: it's -> 'it'\''s'
+func shellQuote(word string) string {
+	return "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+}
+
+// shellWords quotes a list of words to be given to compgen -W, which
+// expands them again, so special characters are escaped in each word
+func shellWords(words []string) string {
+	escaped := make([]string, 0, len(words))
+	for _, word := range words {
+		var buf bytes.Buffer
+		for _, char := range word {
+			if !isLetter(char) && !isDigit(char) && !strings.ContainsRune("_-.,:/@%+=", char) {
+				buf.WriteRune('\\')
+			}
+			buf.WriteRune(char)
+		}
+		escaped = append(escaped, buf.String())
+	}
+	return shellQuote(strings.Join(escaped, " "))
+}
+
+// shellName returns a name for a shell function from the path of a
+// parser, Ex: my-tool remote -> my_tool_remote
+func shellName(path string) string {
+	name := []rune{}
+	for _, char := range path {
+		if isLetter(char) || isDigit(char) {
+			name = append(name, char)
+		} else {
+			name = append(name, '_')
+		}
+	}
+	return string(name)
+}
+
+// bashAction returns the commands of the bash script that complete the
+// value of the argument, at runtime with the dynamic function, from its
+// choices or files. dynamic is empty if values are not completed at runtime.
+func (arg Argument) bashAction(dynamic string) string {
+	if arg.completion != nil && dynamic != "" {
+		return dynamic
+	}
+	if choices := arg.val.getChoices(); len(choices) > 0 {
+		return fmt.Sprintf("COMPREPLY=($(compgen -W %s -- \"$cur\"))", shellWords(choices))
+	}
+	return "COMPREPLY=($(compgen -f -- \"$cur\"))"
+}
+
+// WriteBashCompletion writes a bash completion script for the parser and
+// its subparsers, it can be loaded with: source <(tool completion). Flags
+// are completed after the prefix, subcommands and the choices of
+// positional arguments in position, and choices after the flags that take
+// a value.
+func (parser *ArgParser) WriteBashCompletion(w io.Writer) error {
+	var script bytes.Buffer
+	function := "_" + shellName(parser.name) + "_completion"
+	parsers := parser.allParsers()
+
+	script.WriteString(fmt.Sprintf("# bash completion for %s\n", parser.name))
+
+	dynamic := ""
+	if parser.hasCompletionFuncs() {
+		dynamic = function + "_dynamic"
+	}
+
+	if dynamic != "" {
+		// values completed at runtime by the completion command, without the
+		// = of --flag=value that bash splits in its own word
+		script.WriteString(fmt.Sprintf("%s() {\n", dynamic))
+		script.WriteString("    local word\n    local -a args\n")
+		script.WriteString("    for word in \"${COMP_WORDS[@]:1:COMP_CWORD-1}\"; do\n")
+		script.WriteString("        if [[ \"$word\" != \"=\" ]]; then\n")
+		script.WriteString("            args+=(\"$word\")\n        fi\n    done\n")
+		script.WriteString(fmt.Sprintf("    mapfile -t COMPREPLY < <(\"${COMP_WORDS[0]}\" %s \"${args[@]}\" \"$cur\" 2>/dev/null)\n", shellQuote(parser.completionCommand)))
+		script.WriteString("}\n\n")
+	}
+
+	script.WriteString(fmt.Sprintf("%s() {\n", function))
+	script.WriteString("    local cur prev word path i pos skip terminated\n")
+	script.WriteString("    COMPREPLY=()\n")
+	script.WriteString("    cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	script.WriteString("    prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	script.WriteString(fmt.Sprintf("    path=%s\n", shellQuote(parser.name)))
+	script.WriteString("    pos=0\n    skip=0\n    terminated=0\n\n")
+
+	// walk the words before the current one to find the subcommand, the
+	// position and if the current word is the value of a flag
+	script.WriteString("    for ((i = 1; i < COMP_CWORD; i++)); do\n")
+	script.WriteString("        word=\"${COMP_WORDS[i]}\"\n")
+	script.WriteString("        if [[ $terminated -eq 1 ]]; then\n")
+	script.WriteString("            pos=$((pos + 1))\n            continue\n        fi\n")
+	script.WriteString("        if [[ \"$word\" == \"=\" ]]; then\n")
+	script.WriteString("            skip=1\n            continue\n        fi\n")
+	script.WriteString("        if [[ $skip -eq 1 ]]; then\n")
+	script.WriteString("            skip=0\n            continue\n        fi\n")
+	script.WriteString("        if [[ \"$word\" == \"--\" ]]; then\n")
+	script.WriteString("            terminated=1\n            continue\n        fi\n")
+	script.WriteString("        case \"$path\" in\n")
+	for _, p := range parsers {
+		script.WriteString(fmt.Sprintf("        %s)\n", shellQuote(p.path())))
+		script.WriteString("            case \"$word\" in\n")
+		if flags := p.flagNames(true); len(flags) > 0 {
+			script.WriteString(fmt.Sprintf("            %s)\n                skip=1 ;;\n", strings.Join(flags, "|")))
+		}
+		for _, subparser := range p.Subparsers() {
+			script.WriteString(fmt.Sprintf("            %s)\n", shellQuote(subparser.name)))
+			script.WriteString(fmt.Sprintf("                path=%s\n                pos=0 ;;\n", shellQuote(subparser.path())))
+		}
+		script.WriteString(fmt.Sprintf("            %s*)\n                ;;\n", string(pARAMPREFIX)))
+		script.WriteString("            *)\n                pos=$((pos + 1)) ;;\n")
+		script.WriteString("            esac ;;\n")
+	}
+	script.WriteString("        esac\n    done\n\n")
+
+	// --flag=value is split by bash in --flag, = and value
+	script.WriteString("    if [[ \"$cur\" == \"=\" ]]; then\n")
+	script.WriteString("        cur=\"\"\n        skip=1\n")
+	script.WriteString("    elif [[ \"$prev\" == \"=\" ]]; then\n")
+	script.WriteString("        prev=\"${COMP_WORDS[COMP_CWORD-2]}\"\n        skip=1\n    fi\n\n")
+
+	script.WriteString("    case \"$path\" in\n")
+	for _, p := range parsers {
+		script.WriteString(fmt.Sprintf("    %s)\n", shellQuote(p.path())))
+
+		// value of a flag
+		script.WriteString("        if [[ $skip -eq 1 ]]; then\n")
+		script.WriteString("            case \"$prev\" in\n")
+		for _, arg := range p.Arguments() {
+			choices := arg.val.getChoices()
+			if !arg.takesValue() || (len(choices) == 0 && (arg.completion == nil || dynamic == "")) {
+				continue
+			}
+			script.WriteString(fmt.Sprintf("            %s)\n", strings.Join(arg.flagNames(), "|")))
+			script.WriteString("                " + arg.bashAction(dynamic) + " ;;\n")
+		}
+		script.WriteString("            *)\n                COMPREPLY=($(compgen -f -- \"$cur\")) ;;\n")
+		script.WriteString("            esac\n            return 0\n        fi\n")
+
+		// flags
+		script.WriteString(fmt.Sprintf("        if [[ $terminated -eq 0 && \"$cur\" == %s* ]]; then\n", string(pARAMPREFIX)))
+		script.WriteString(fmt.Sprintf("            COMPREPLY=($(compgen -W %s -- \"$cur\"))\n", shellWords(p.flagNames(false))))
+		script.WriteString("            return 0\n        fi\n")
+
+		// positional arguments and then subcommands
+		script.WriteString("        case $pos in\n")
+		for i, arg := range p.posArguments {
+			script.WriteString(fmt.Sprintf("        %d)\n", i))
+			script.WriteString("            " + arg.bashAction(dynamic) + " ;;\n")
+		}
+		script.WriteString("        *)\n")
+		if len(p.subparsers) > 0 {
+			script.WriteString("            if [[ $terminated -eq 0 ]]; then\n")
+			script.WriteString(fmt.Sprintf("                COMPREPLY=($(compgen -W %s -- \"$cur\"))\n", shellWords(p.subparserNames())))
+			script.WriteString("            else\n")
+			script.WriteString("                COMPREPLY=($(compgen -f -- \"$cur\"))\n")
+			script.WriteString("            fi ;;\n")
+		} else {
+			script.WriteString("            COMPREPLY=($(compgen -f -- \"$cur\")) ;;\n")
+		}
+		script.WriteString("        esac ;;\n")
+	}
+	script.WriteString("    esac\n    return 0\n}\n\n")
+
+	script.WriteString(fmt.Sprintf("complete -o filenames -F %s %s\n", function, parser.name))
+
+	_, err := w.Write(script.Bytes())
+	return err
+}
+
+// zshEscape escapes the characters with special meaning in the specs of
+// _arguments, the result is quoted with shellQuote
+func zshEscape(text string, special string) string {
+	var buf bytes.Buffer
+	for _, char := range text {
+		if strings.ContainsRune(special, char) {
+			buf.WriteRune('\\')
+		}
+		buf.WriteRune(char)
+	}
+	return buf.String()
+}
+
+// valueName returns the name of the value of an argument shown by the
+// shells, Ex: port for --port
+func (arg Argument) valueName() string {
+	if arg.positional {
+		return arg.name
+	}
+	if arg.name != "" {
+		return strings.TrimLeft(arg.name, string(pARAMPREFIX))
+	}
+	return string(arg.shortcut)
+}
+
+// zshAction returns the action of _arguments to complete the value of the
+// argument, the dynamic function, its choices or files. dynamic is empty if
+// values are not completed at runtime.
+func (arg Argument) zshAction(dynamic string) string {
+	if arg.completion != nil && dynamic != "" {
+		return dynamic
+	}
+
+	choices := arg.val.getChoices()
+	if len(choices) == 0 {
+		return "_files"
+	}
+
+	escaped := make([]string, 0, len(choices))
+	for _, choice := range choices {
+		escaped = append(escaped, zshEscape(choice, " ()\\:"))
+	}
+	return "(" + strings.Join(escaped, " ") + ")"
+}
+
+// zshSpec returns the spec of _arguments for a flag, the arguments of an
+// exclusive group exclude each other
+func (parser *ArgParser) zshSpec(arg *Argument, dynamic string) string {
+	names := arg.flagNames()
+
+	exclusion := ""
+	if arg.action == ActionHelp {
+		exclusion = "(- *)"
+	} else if grp, ok := parser.getArgumentGroup(arg); ok && grp.exclusive {
+		excluded := []string{}
+		for _, grpArg := range grp.arguments {
+			excluded = append(excluded, grpArg.flagNames()...)
+		}
+		exclusion = "(" + strings.Join(excluded, " ") + ")"
+	} else if arg.action != ActionAppend && arg.action != ActionIncrement {
+		exclusion = "(" + strings.Join(names, " ") + ")"
+	}
+
+	if arg.action == ActionAppend || arg.action == ActionIncrement {
+		exclusion += "*"
+	}
+
+	spec := "[" + zshEscape(arg.description, "[]") + "]"
+	if arg.takesValue() {
+		spec += ":" + zshEscape(arg.valueName(), ":") + ":" + arg.zshAction(dynamic)
+	}
+
+	if len(names) == 1 {
+		return shellQuote(exclusion + names[0] + spec)
+	}
+	return shellQuote(exclusion) + "{" + strings.Join(names, ",") + "}" + shellQuote(spec)
+}
+
+// WriteZshCompletion writes a zsh completion script for the parser and its
+// subparsers, it can be installed as _tool in $fpath or loaded with:
+// source <(tool completion). The descriptions of the arguments and
+// subcommands are shown next to the candidates.
+func (parser *ArgParser) WriteZshCompletion(w io.Writer) error {
+	var script bytes.Buffer
+	function := "_" + shellName(parser.name)
+	dynamic := ""
+	if parser.hasCompletionFuncs() {
+		dynamic = function + "_dynamic"
+	}
+	words := function + "_words"
+
+	script.WriteString(fmt.Sprintf("#compdef %s\n", parser.name))
+
+	if dynamic != "" {
+		// values completed at runtime by the completion command, the words
+		// of the command line are saved by the first function because
+		// _arguments removes the subcommands from words
+		script.WriteString(fmt.Sprintf("\n%s() {\n", dynamic))
+		script.WriteString("    local -a candidates\n")
+		script.WriteString(fmt.Sprintf("    candidates=(${(f)\"$(${%s[1]} %s \"${(@)%s[2,%s_current]}\" 2>/dev/null)\"})\n", words, shellQuote(parser.completionCommand), words, function))
+		script.WriteString("    compadd -a candidates\n}\n")
+	}
+
+	for _, p := range parser.allParsers() {
+		script.WriteString(fmt.Sprintf("\n_%s() {\n", shellName(p.path())))
+		script.WriteString("    local context state state_descr line\n")
+		script.WriteString("    typeset -A opt_args\n")
+		if p == parser && dynamic != "" {
+			script.WriteString(fmt.Sprintf("    local -a %s=(\"${(@)words}\")\n", words))
+			script.WriteString(fmt.Sprintf("    local %s_current=$CURRENT\n", function))
+		}
+		script.WriteString("\n")
+
+		if len(p.arguments) == 0 && len(p.subparsers) == 0 {
+			script.WriteString("    _message 'no more arguments'\n}\n")
+			continue
+		}
+
+		script.WriteString("    _arguments -C -s")
+
+		for _, arg := range p.Arguments() {
+			script.WriteString(" \\\n        " + p.zshSpec(arg, dynamic))
+		}
+
+		for i, arg := range p.posArguments {
+			message := arg.description
+			if message == "" {
+				message = arg.name
+			}
+			script.WriteString(" \\\n        " + shellQuote(fmt.Sprintf("%d:%s:%s", i + 1, zshEscape(message, ":"), arg.zshAction(dynamic))))
+		}
+
+		if len(p.subparsers) == 0 {
+			script.WriteString("\n}\n")
+			continue
+		}
+
+		script.WriteString(" \\\n        " + shellQuote(fmt.Sprintf("%d: :->command", len(p.posArguments) + 1)))
+		script.WriteString(" \\\n        " + shellQuote("*:: :->args"))
+		script.WriteString(" \\\n        && return 0\n\n")
+
+		script.WriteString("    case $state in\n")
+		script.WriteString("        (command)\n")
+		script.WriteString("            local -a commands\n")
+		script.WriteString("            commands=(\n")
+		for _, subparser := range p.Subparsers() {
+			candidate := zshEscape(subparser.name, ":") + ":" + subparser.description
+			script.WriteString("                " + shellQuote(candidate) + "\n")
+		}
+		script.WriteString("            )\n")
+		script.WriteString(fmt.Sprintf("            _describe -t commands %s commands ;;\n", shellQuote(p.path() + " command")))
+		script.WriteString("        (args)\n")
+		if len(p.posArguments) > 0 {
+			// words begins with the positional arguments
+			script.WriteString(fmt.Sprintf("            shift %d words\n", len(p.posArguments)))
+			script.WriteString(fmt.Sprintf("            (( CURRENT -= %d ))\n", len(p.posArguments)))
+		}
+		script.WriteString("            case $words[1] in\n")
+		for _, subparser := range p.Subparsers() {
+			script.WriteString(fmt.Sprintf("                (%s)\n", subparser.name))
+			script.WriteString(fmt.Sprintf("                    _%s ;;\n", shellName(subparser.path())))
+		}
+		script.WriteString("            esac ;;\n")
+		script.WriteString("    esac\n}\n")
+	}
+
+	script.WriteString("\nif [[ $zsh_eval_context[-1] == loadautofunc ]]; then\n")
+	script.WriteString(fmt.Sprintf("    %s \"$@\"\nelse\n", function))
+	script.WriteString(fmt.Sprintf("    compdef %s %s\nfi\n", function, parser.name))
+
+	_, err := w.Write(script.Bytes())
+	return err
+}
+
+// fishQuote quotes a word for fish, Ex: it's -> 'it\'s'
+func fishQuote(word string) string {
+	return "'" + zshEscape(word, "\\'") + "'"
+}
+
+// WriteFishCompletion writes a fish completion script for the parser and
+// its subparsers, it can be installed in ~/.config/fish/completions or
+// loaded with: tool completion | source. The descriptions of the arguments
+// and subcommands are shown next to the candidates.
+func (parser *ArgParser) WriteFishCompletion(w io.Writer) error {
+	var script bytes.Buffer
+	function := "__" + shellName(parser.name)
+	parsers := parser.allParsers()
+	dynamic := parser.hasCompletionFuncs()
+
+	script.WriteString(fmt.Sprintf("# fish completion for %s\n\n", parser.name))
+
+	// the path of the subcommand being completed, Ex: tool remote add
+	script.WriteString(fmt.Sprintf("function %s_path\n", function))
+	script.WriteString("    set -l tokens (commandline -opc)\n")
+	script.WriteString(fmt.Sprintf("    set -l path %s\n", fishQuote(parser.name)))
+	script.WriteString("    set -l skip 0\n")
+	script.WriteString("    for token in $tokens[2..-1]\n")
+	script.WriteString("        if test $skip -eq 1\n")
+	script.WriteString("            set skip 0\n            continue\n        end\n")
+	script.WriteString("        if test \"$token\" = --\n")
+	script.WriteString("            break\n        end\n")
+	script.WriteString("        switch $path\n")
+	for _, p := range parsers {
+		flags := p.flagNames(true)
+		if len(flags) == 0 && len(p.subparsers) == 0 {
+			continue
+		}
+		script.WriteString(fmt.Sprintf("            case %s\n", fishQuote(p.path())))
+		script.WriteString("                switch $token\n")
+		if len(flags) > 0 {
+			script.WriteString(fmt.Sprintf("                    case %s\n", strings.Join(flags, " ")))
+			script.WriteString("                        set skip 1\n")
+		}
+		for _, subparser := range p.Subparsers() {
+			script.WriteString(fmt.Sprintf("                    case %s\n", fishQuote(subparser.name)))
+			script.WriteString(fmt.Sprintf("                        set path %s\n", fishQuote(subparser.path())))
+		}
+		script.WriteString("                end\n")
+	}
+	script.WriteString("        end\n    end\n")
+	script.WriteString("    echo $path\nend\n\n")
+
+	script.WriteString(fmt.Sprintf("function %s_using_path\n", function))
+	script.WriteString(fmt.Sprintf("    test (%s_path) = \"$argv[1]\"\nend\n", function))
+
+	if dynamic {
+		// values completed at runtime by the completion command,
+		// --flag=value is given as two arguments to complete only the value
+		script.WriteString(fmt.Sprintf("\nfunction %s_dynamic\n", function))
+		script.WriteString("    set -l tokens (commandline -opc)\n")
+		script.WriteString("    set -l current (commandline -ct)\n")
+		script.WriteString("    if string match -qr -- '^--[^=]+=' $current\n")
+		script.WriteString("        set -a tokens (string replace -r -- '=.*' '' $current)\n")
+		script.WriteString("        set current (string replace -r -- '^[^=]*=' '' $current)\n")
+		script.WriteString("    end\n")
+		script.WriteString(fmt.Sprintf("    $tokens[1] %s $tokens[2..-1] $current 2>/dev/null\nend\n", fishQuote(parser.completionCommand)))
+	}
+
+	for _, p := range parsers {
+		condition := fmt.Sprintf("%s_using_path %s", function, fishQuote(p.path()))
+		script.WriteString(fmt.Sprintf("\n# %s\n", p.path()))
+
+		for _, arg := range p.Arguments() {
+			line := fmt.Sprintf("complete -c %s", parser.name)
+
+			argCondition := condition
+			if grp, ok := p.getArgumentGroup(arg); ok && grp.exclusive {
+				for _, grpArg := range grp.arguments {
+					if grpArg == arg {
+						continue
+					}
+					argCondition += "; and not __fish_contains_opt"
+					if grpArg.shortcut != NOSHORTCUT {
+						argCondition += " -s " + string(grpArg.shortcut)
+					}
+					if grpArg.name != "" {
+						argCondition += " " + strings.TrimLeft(grpArg.name, string(pARAMPREFIX))
+					}
+				}
+			}
+			line += " -n " + fishQuote(argCondition)
+
+			if arg.name != "" {
+				line += " -l " + strings.TrimLeft(arg.name, string(pARAMPREFIX))
+			}
+			if arg.shortcut != NOSHORTCUT {
+				line += " -s " + string(arg.shortcut)
+			}
+			if arg.takesValue() {
+				if arg.completion != nil && dynamic {
+					line += " -x -a " + fishQuote("(" + function + "_dynamic)")
+				} else if choices := arg.val.getChoices(); len(choices) > 0 {
+					line += " -x -a " + fishQuote(strings.Join(choices, " "))
+				} else {
+					line += " -r"
+				}
+			}
+			line += " -d " + fishQuote(arg.description)
+			script.WriteString(line + "\n")
+		}
+
+		for _, arg := range p.posArguments {
+			if arg.completion != nil && dynamic {
+				script.WriteString(fmt.Sprintf("complete -c %s -n %s -f -a %s -d %s\n", parser.name, fishQuote(condition), fishQuote("(" + function + "_dynamic)"), fishQuote(arg.description)))
+			} else if choices := arg.val.getChoices(); len(choices) > 0 {
+				script.WriteString(fmt.Sprintf("complete -c %s -n %s -f -a %s -d %s\n", parser.name, fishQuote(condition), fishQuote(strings.Join(choices, " ")), fishQuote(arg.description)))
+			}
+		}
+
+		for _, subparser := range p.Subparsers() {
+			script.WriteString(fmt.Sprintf("complete -c %s -n %s -f -a %s -d %s\n", parser.name, fishQuote(condition), fishQuote(subparser.name), fishQuote(subparser.description)))
+		}
+	}
+
+	_, err := w.Write(script.Bytes())
+	return err
+}
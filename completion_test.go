@@ -0,0 +1,172 @@
+package argparse
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// newToolParser returns the parser of the golden files: a nested
+// subparser, an exclusive group, choices and environment variables
+func newToolParser(t *testing.T) *ArgParser {
+	t.Helper()
+	parser, err := NewArgParser("tool", "Manages the remotes of a repository.", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.SetEnvPrefix("TOOL")
+
+	must := func(_ interface{}, err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	format, err := parser.AddArgumentsGroup("format", "output format", false, true)
+	must(format, err)
+	must(parser.Bool("--verbose", Short('v'), Help("print more details")))
+	must(format.String("--output", Short('o'), Help("output as"), Default("text"), Choices("json", "text", "yaml")))
+	must(format.Bool("--raw", Help("raw output")))
+	must(parser.String("--token", Help("api token"), Env("API_TOKEN")))
+
+	remote, err := parser.AddSubparser("remote", "Manage remotes", true)
+	must(remote, err)
+	must(remote.Int("--timeout", Help("seconds to wait"), Default(30)))
+
+	add, err := remote.AddSubparser("add", "Add a remote", true)
+	must(add, err)
+	must(add.String("name", Help("remote name")))
+	must(add.String("kind", Help("version control"), Choices("git", "hg")))
+	must(add.StringSlice("--tag", Short('t'), Help("tags of the remote")))
+
+	status, err := parser.AddSubparser("status", "Show the status", false)
+	must(status, err)
+	must(status.Bool("--short", Short('s'), Help("one line per file")))
+
+	return parser
+}
+
+func TestWriteBashCompletion(t *testing.T) {
+	var script bytes.Buffer
+	err := newToolParser(t).WriteBashCompletion(&script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "tool.bash", script.Bytes())
+
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not installed")
+	}
+
+	out, err := exec.Command(bash, "-n", "-c", script.String()).CombinedOutput()
+	if err != nil {
+		t.Fatalf("bash -n: %s\n%s", err, out)
+	}
+
+	// complete is a builtin, so the completions can be run with COMP_WORDS
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"tool --o", "--output"},
+		{"tool -o ", "json text yaml"},
+		{"tool --output=y", "yaml"},
+		{"tool -v re", "remote"},
+		{"tool remote ", "add"},
+		{"tool remote --timeout 5 add origin ", "git hg"},
+		{"tool remote add origin g", "git"},
+		{"tool status --s", "--short"},
+		{"tool -- s", ""},
+	}
+	for _, test := range tests {
+		words := strings.Fields(test.line)
+		if strings.HasSuffix(test.line, " ") {
+			words = append(words, "")
+		}
+		if strings.Contains(test.line, "=") {
+			// bash splits --flag=value in --flag, = and value
+			last := words[len(words)-1]
+			flag, value, _ := strings.Cut(last, "=")
+			words = append(words[:len(words)-1], flag, "=", value)
+		}
+
+		quoted := make([]string, 0, len(words))
+		for _, word := range words {
+			quoted = append(quoted, shellQuote(word))
+		}
+		run := script.String() + "\nCOMP_WORDS=(" + strings.Join(quoted, " ") + ")\n" +
+			"COMP_CWORD=$((${#COMP_WORDS[@]} - 1))\n" +
+			"cd \"$(mktemp -d)\"\n" +
+			"_tool_completion\necho \"${COMPREPLY[*]}\"\n"
+
+		out, err := exec.Command(bash, "-c", run).CombinedOutput()
+		if err != nil {
+			t.Errorf("%q: %s\n%s", test.line, err, out)
+		} else if got := strings.TrimSpace(string(out)); got != test.want {
+			t.Errorf("%q: got completions %q, want %q", test.line, got, test.want)
+		}
+	}
+}
+
+func TestWriteZshCompletion(t *testing.T) {
+	parser := newToolParser(t)
+
+	var script bytes.Buffer
+	err := parser.WriteZshCompletion(&script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "tool.zsh", script.Bytes())
+
+	// the members of an exclusive group exclude each other
+	output, _ := parser.Argument("--output")
+	want := `'(--output -o --raw)'{--output,-o}'[output as]:output:(json text yaml)'`
+	if spec := parser.zshSpec(output, "_tool_dynamic"); spec != want {
+		t.Errorf("got spec %s, want %s", spec, want)
+	}
+	if zsh, err := exec.LookPath("zsh"); err == nil {
+		out, err := exec.Command(zsh, "-n", "-c", script.String()).CombinedOutput()
+		if err != nil {
+			t.Errorf("zsh -n: %s\n%s", err, out)
+		}
+	}
+}
+
+func TestWriteFishCompletion(t *testing.T) {
+	var script bytes.Buffer
+	err := newToolParser(t).WriteFishCompletion(&script)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "tool.fish", script.Bytes())
+
+	if fish, err := exec.LookPath("fish"); err == nil {
+		out, err := exec.Command(fish, "--no-execute", "-c", script.String()).CombinedOutput()
+		if err != nil {
+			t.Errorf("fish --no-execute: %s\n%s", err, out)
+		}
+	}
+}
+
+func TestShellQuoting(t *testing.T) {
+	tests := []struct {
+		quote func(string) string
+		word  string
+		want  string
+	}{
+		{shellQuote, "it's", `'it'\''s'`},
+		{fishQuote, `it's a \`, `'it\'s a \\'`},
+		{shellName, "my-tool remote", "my_tool_remote"},
+		{func(word string) string { return zshEscape(word, "[]") }, "[default]", `\[default\]`},
+		{func(word string) string { return shellWords([]string{word, "b"}) }, "a b$", `'a\ b\$ b'`},
+	}
+
+	for _, test := range tests {
+		if got := test.quote(test.word); got != test.want {
+			t.Errorf("%q: got %s, want %s", test.word, got, test.want)
+		}
+	}
+}
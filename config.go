@@ -0,0 +1,355 @@
+package argparse
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+/*
+Config files set the arguments that are not given in the command line nor
+in the environment. Keys are the names of the arguments without dashes
+(max-size for --max-size) and sections are subparsers.
+
+JSON:
+
+	{"port": 8080, "include": ["a", "b"], "remote": {"url": "..."}}
+
+INI:
+
+	port = 8080
+	include = a
+	include = b
+
+	[remote]
+	url = ...
+
+	[remote.add]
+	...
+*/
+
+// configSection is a level of a config file, the values of the arguments of
+// a parser and the sections of its subparsers
+type configSection struct {
+	values   map[string][]string
+	objects  map[string]map[string]string // JSON objects, for maps arguments
+	sections map[string]*configSection
+}
+
+func newConfigSection() *configSection {
+	section := new(configSection)
+	section.values = map[string][]string{}
+	section.objects = map[string]map[string]string{}
+	section.sections = map[string]*configSection{}
+	return section
+}
+
+// SetConfigFile sets the config file read in Parse, it is ignored if it
+// does not exist. Files ending in .json are JSON, the rest are INI.
+func (parser *ArgParser) SetConfigFile(path string) {
+	parser.configFile = path
+}
+
+// SetConfigArgument sets a string argument of the parser (Ex: --config)
+// whose value is the config file to read, it overrides SetConfigFile
+func (parser *ArgParser) SetConfigArgument(name string) error {
+	arg, exists := parser.getArgument(name)
+	if !exists {
+		return fmt.Errorf("Argument %s is not defined in parser %s", name, parser.name)
+	}
+
+	if _, ok := arg.val.(stringValue); !ok || !arg.takesValue() {
+		return fmt.Errorf("Config argument %s must be a string argument", arg.name)
+	}
+
+	parser.configArgument = arg
+	return nil
+}
+
+// loadConfig returns the config of the parser, from its own config file or
+// the section given by its parent
+func (parser *ArgParser) loadConfig() (*configSection, error) {
+	path := parser.configFile
+	explicit := false
+
+	if parser.configArgument != nil && parser.configArgument.get() != "" {
+		path = parser.configArgument.get()
+		explicit = true
+	}
+
+	if path == "" {
+		return parser.parentConfig, nil
+	}
+
+	section, err := readConfigFile(path)
+	if os.IsNotExist(err) && !explicit {
+		return parser.parentConfig, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	return section, nil
+}
+
+// setFromConfig sets the arguments that were not given from the config,
+// except if another argument of their exclusive group was given
+func (parser *ArgParser) setFromConfig(argsSet map[*Argument]Source) error {
+	section, err := parser.loadConfig()
+	if err != nil {
+		return err
+	}
+	if section == nil {
+		return nil
+	}
+	parser.config = section
+
+	path := parser.configFile
+	if parser.configArgument != nil && parser.configArgument.get() != "" {
+		path = parser.configArgument.get()
+	}
+
+	for key := range section.sections {
+		if !parser.existsSubparser(key) {
+			if _, isMap := section.objects[key]; !isMap {
+				return fmt.Errorf("config file %s: unknown section %s in %s", path, key, parser.path())
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(section.values) + len(section.objects))
+	for key := range section.values {
+		keys = append(keys, key)
+	}
+	for key := range section.objects {
+		if !parser.existsSubparser(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	ConfigLoop: for _, key := range keys {
+		arg, exists := parser.getArgument("--" + key)
+		if !exists || arg.action == ActionHelp || arg == parser.configArgument {
+			return fmt.Errorf("config file %s: unknown key %s in %s", path, key, parser.path())
+		}
+
+		if _, wasSet := argsSet[arg]; wasSet {
+			continue
+		}
+
+		if grp, ok := parser.getArgumentGroup(arg); ok && grp.exclusive {
+			for _, grpArg := range grp.arguments {
+				if _, wasSet := argsSet[grpArg]; wasSet {
+					continue ConfigLoop
+				}
+			}
+		}
+
+		values := section.values[key]
+		if object, isObject := section.objects[key]; isObject {
+			values, err = objectToPairs(arg, object)
+			if err != nil {
+				return fmt.Errorf("config file %s: key %s: %w", path, key, err)
+			}
+		}
+
+		if arg.action != ActionAppend && len(values) > 1 {
+			return fmt.Errorf("config file %s: key %s has more than one value", path, key)
+		}
+
+		for _, value := range values {
+			err = arg.setExternal(value)
+			if err != nil {
+				return fmt.Errorf("config file %s: %w", path, err)
+			}
+		}
+		argsSet[arg] = SourceConfigFile
+	}
+
+	return nil
+}
+
+// objectToPairs converts a JSON object to the key=value pairs of a map
+// argument
+func objectToPairs(arg *Argument, object map[string]string) ([]string, error) {
+	separator := ""
+	switch val := arg.val.(type) {
+		case stringMapValue:
+			separator = val.separator
+		case intMapValue:
+			separator = val.separator
+		default:
+			return nil, fmt.Errorf("argument %s is not a map", arg.name)
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, key + separator + object[key])
+	}
+	return pairs, nil
+}
+
+func readConfigFile(path string) (*configSection, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		return parseJSONConfig(file)
+	}
+	return parseINIConfig(file)
+}
+
+func parseJSONConfig(file *os.File) (*configSection, error) {
+	var root map[string]interface{}
+
+	decoder := json.NewDecoder(file)
+	decoder.UseNumber()
+	if err := decoder.Decode(&root); err != nil {
+		return nil, err
+	}
+
+	return jsonSection(root)
+}
+
+func jsonSection(object map[string]interface{}) (*configSection, error) {
+	section := newConfigSection()
+
+	for key, value := range object {
+		key = strings.ToLower(key)
+
+		switch v := value.(type) {
+			case nil:
+				continue
+
+			case map[string]interface{}:
+				// it can be a subparser or a map argument
+				sub, err := jsonSection(v)
+				if err != nil {
+					return nil, err
+				}
+				section.sections[key] = sub
+
+				if pairs, ok := jsonObjectStrings(v); ok {
+					section.objects[key] = pairs
+				}
+
+			case []interface{}:
+				for _, item := range v {
+					str, ok := jsonScalar(item)
+					if !ok {
+						return nil, fmt.Errorf("invalid value in list %s", key)
+					}
+					section.values[key] = append(section.values[key], str)
+				}
+
+			default:
+				str, ok := jsonScalar(v)
+				if !ok {
+					return nil, fmt.Errorf("invalid value of %s", key)
+				}
+				section.values[key] = []string{str}
+		}
+	}
+
+	return section, nil
+}
+
+func jsonScalar(value interface{}) (string, bool) {
+	switch v := value.(type) {
+		case string:
+			return v, true
+		case json.Number:
+			return v.String(), true
+		case bool:
+			return fmt.Sprint(v), true
+	}
+	return "", false
+}
+
+// jsonObjectStrings converts an object of scalars to strings
+func jsonObjectStrings(object map[string]interface{}) (map[string]string, bool) {
+	pairs := map[string]string{}
+	for key, value := range object {
+		str, ok := jsonScalar(value)
+		if !ok {
+			return nil, false
+		}
+		pairs[key] = str
+	}
+	return pairs, true
+}
+
+func parseINIConfig(file *os.File) (*configSection, error) {
+	root := newConfigSection()
+	section := root
+	lineNumber := 0
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+
+		if line[0] == '[' {
+			if line[len(line)-1] != ']' {
+				return nil, fmt.Errorf("line %d: invalid section", lineNumber)
+			}
+
+			// [remote.add] is the section of subparser add of remote
+			section = root
+			for _, name := range strings.Split(line[1:len(line)-1], ".") {
+				name = strings.ToLower(strings.TrimSpace(name))
+				sub, exists := section.sections[name]
+				if !exists {
+					sub = newConfigSection()
+					section.sections[name] = sub
+				}
+				section = sub
+			}
+			continue
+		}
+
+		kv := strings.SplitN(line, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("line %d: expected key = value", lineNumber)
+		}
+
+		key := strings.ToLower(strings.TrimSpace(kv[0]))
+		value := unquote(strings.TrimSpace(kv[1]))
+		section.values[key] = append(section.values[key], value)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return root, nil
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 {
+		first := value[0]
+		last := value[len(value)-1]
+		if (first == '"' || first == '\'') && first == last {
+			return value[1:len(value)-1]
+		}
+	}
+	return value
+}
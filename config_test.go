@@ -0,0 +1,203 @@
+package argparse
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	err := os.WriteFile(path, []byte(content), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	files := map[string]string{
+		"tool.json": `{"port": 1, "host": "config", "verbose": true, "include": ["a", "b"]}`,
+		"tool.ini":  "# defaults\nport = 1\nhost = \"config\"\nverbose = true\ninclude = a\ninclude = b\n",
+	}
+
+	for name, content := range files {
+		path := writeConfig(t, name, content)
+
+		parser, err := NewArgParser("tool", "", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parser.SetEnvPrefix("TOOL")
+		parser.SetConfigFile(path)
+		port, _ := parser.Int("--port", Default(80))
+		host, _ := parser.String("--host", Default("localhost"))
+		verbose, _ := parser.Bool("--verbose")
+		include, err := parser.StringSlice("--include")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// config only
+		err = parser.Parse([]string{"tool"})
+		if err != nil {
+			t.Fatalf("%s: unexpected error %s", name, err)
+		}
+		if *port != 1 || *host != "config" || !*verbose || !reflect.DeepEqual(*include, []string{"a", "b"}) {
+			t.Errorf("%s: got port=%d host=%s verbose=%t include=%v", name, *port, *host, *verbose, *include)
+		}
+
+		// environment over config, command line over both
+		t.Setenv("TOOL_PORT", "2")
+		t.Setenv("TOOL_HOST", "env")
+		err = parser.Parse([]string{"tool", "--host", "cli", "--include", "c"})
+		if err != nil {
+			t.Fatalf("%s: unexpected error %s", name, err)
+		}
+		if *port != 2 || *host != "cli" || !reflect.DeepEqual(*include, []string{"c"}) {
+			t.Errorf("%s: got port=%d host=%s include=%v", name, *port, *host, *include)
+		}
+		os.Unsetenv("TOOL_PORT")
+		os.Unsetenv("TOOL_HOST")
+	}
+}
+
+func TestConfigSections(t *testing.T) {
+	ini := writeConfig(t, "tool.ini", `
+level = 1
+
+[remote]
+name = origin
+
+[remote.add]
+; nested subparser
+url = https://example.com
+label = env=prod
+label = team=core
+`)
+	json := writeConfig(t, "tool.json", `{
+	"level": 1,
+	"remote": {
+		"name": "origin",
+		"add": {"url": "https://example.com", "label": {"env": "prod", "team": "core"}}
+	}
+}`)
+
+	for _, path := range []string{ini, json} {
+		parser, err := NewArgParser("tool", "", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parser.SetConfigFile(path)
+		level, _ := parser.Int("--level")
+		remote, _ := parser.AddSubparser("remote", "", true)
+		name, _ := remote.String("--name")
+		add, _ := remote.AddSubparser("add", "", true)
+		url, _ := add.String("--url")
+		label, err := add.StringMap("--label")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = parser.Parse([]string{"tool", "remote", "add"})
+		if err != nil {
+			t.Fatalf("%s: unexpected error %s", filepath.Base(path), err)
+		}
+		if *level != 1 || *name != "origin" || *url != "https://example.com" {
+			t.Errorf("%s: got level=%d name=%s url=%s", filepath.Base(path), *level, *name, *url)
+		}
+		if !reflect.DeepEqual(*label, map[string]string{"env": "prod", "team": "core"}) {
+			t.Errorf("%s: got label %v", filepath.Base(path), *label)
+		}
+	}
+}
+
+func TestConfigArgument(t *testing.T) {
+	path := writeConfig(t, "other.ini", "port = 9\n")
+
+	parser, err := NewArgParser("tool", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser.SetConfigFile(filepath.Join(t.TempDir(), "missing.ini"))
+	port, _ := parser.Int("--port")
+	_, err = parser.String("--config", Short('c'))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = parser.Int("--count")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = parser.SetConfigArgument("--config")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a missing default config file is ignored
+	err = parser.Parse([]string{"tool"})
+	if err != nil || *port != 0 {
+		t.Errorf("got error %v and port %d", err, *port)
+	}
+
+	err = parser.Parse([]string{"tool", "-c", path})
+	if err != nil || *port != 9 {
+		t.Errorf("got error %v and port %d", err, *port)
+	}
+
+	// but a missing file given in the command line is an error
+	err = parser.Parse([]string{"tool", "-c", filepath.Join(t.TempDir(), "missing.ini")})
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("got error %v, want a missing file", err)
+	}
+
+	if err := parser.SetConfigArgument("--count"); err == nil {
+		t.Errorf("expected an error for a non string argument")
+	}
+	if err := parser.SetConfigArgument("--missing"); err == nil {
+		t.Errorf("expected an error for an undefined argument")
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		reason  string
+	}{
+		{"unknown.ini", "colour = red\n", "unknown key colour in tool"},
+		{"section.ini", "[remot]\n", "unknown section remot in tool"},
+		{"help.json", `{"help": true}`, "unknown key help in tool"},
+		{"invalid.json", `{"port": "http"}`, `Invalid value "http" for argument --port, must be an integer`},
+		{"repeated.ini", "port = 1\nport = 2\n", "key port has more than one value"},
+		{"syntax.ini", "port = 1\n[remote\n", "line 2: invalid section"},
+		{"pair.ini", "port\n", "line 1: expected key = value"},
+		{"list.json", `{"include": [["a"]]}`, "invalid value in list include"},
+		{"broken.json", `{"port": `, "unexpected EOF"},
+	}
+
+	for _, test := range tests {
+		path := writeConfig(t, test.name, test.content)
+
+		parser, err := NewArgParser("tool", "", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parser.SetConfigFile(path)
+		_, _ = parser.Int("--port")
+		_, _ = parser.StringSlice("--include")
+		_, err = parser.AddSubparser("remote", "", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = parser.Parse([]string{"tool"})
+		if err == nil || !strings.HasPrefix(err.Error(), "config file "+path+": ") || !strings.HasSuffix(err.Error(), test.reason) {
+			t.Errorf("%s: got error %v, want %q", test.name, err, test.reason)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package argparse
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Value is the interface to implement custom argument types, Ex: IP
+// addresses or versions, see AddValue
+type Value interface {
+	// String returns the current value as it is shown in Help
+	String() string
+	// Set parses and stores the value given in the command line
+	Set(string) error
+}
+
+// ClonableValue is a Value that can be copied, AddValue requires it to
+// restore the default of the Value between parses and ParseArgs to parse the
+// arguments without changing the Value given to AddValue
+type ClonableValue interface {
+	Value
+	// Clone returns a copy of the Value that does not share its state
+	Clone() Value
+}
+
+// packageQualifier matches the package of the types, Ex: net. in []net.IP
+var packageQualifier = regexp.MustCompile(`[A-Za-z0-9_]+\.`)
+
+// shortTypeName removes the packages from the name of a type, Ex:
+// []net.IP -> []IP
+func shortTypeName(name string) string {
+	return packageQualifier.ReplaceAllString(name, "")
+}
+
+// ==CLASS customValue BEGIN==
+type customValue struct {
+	val      ClonableValue
+	snapshot ClonableValue // copy of val when it was added, never changed
+	changed  *bool
+}
+
+func newCustomValue(val ClonableValue) customValue {
+	custom := customValue{}
+	custom.val = val
+	custom.snapshot = val.Clone().(ClonableValue)
+	custom.changed = new(bool)
+
+	return custom
+}
+
+func (val customValue) get() string {
+	return val.val.String()
+}
+
+func (val customValue) getDefault() string {
+	return val.snapshot.String()
+}
+
+// typeName returns the type of the Value, Ex: IP for *main.IP
+func (val customValue) typeName() string {
+	return shortTypeName(strings.TrimLeft(fmt.Sprintf("%T", val.val), "*"))
+}
+
+func (val customValue) getChoices() []string {
+	return nil
+}
+
+func (val customValue) set(value string, name string, shortcut rune) error {
+	err := val.val.Set(value)
+	if err != nil {
+		return invalidValue(value, name, shortcut, err.Error())
+	}
+
+	*(val.changed) = true
+	return nil
+}
+
+func (val customValue) getRaw() interface{} {
+	return val.val
+}
+
+// clone returns a copy of the Value at its default
+func (val customValue) clone() value {
+	c := val
+	c.val = val.snapshot.Clone().(ClonableValue)
+	c.changed = new(bool)
+	return c
+}
+
+func (val customValue) setDefault() {
+	// the Value keeps its own default, it is only restored if it changed,
+	// from a copy of the snapshot because Set may append to the Value
+	if *(val.changed) {
+		reflect.ValueOf(val.val).Elem().Set(reflect.ValueOf(val.snapshot.Clone()).Elem())
+		*(val.changed) = false
+	}
+}
+
+func (val customValue) setTrue() error {
+	return fmt.Errorf("Invalid action for custom value: Store True")
+}
+
+func (val customValue) setFalse() error {
+	return fmt.Errorf("Invalid action for custom value: Store False")
+}
+
+func (val customValue) setConstant() error {
+	return fmt.Errorf("Invalid action for custom value: Store Const")
+}
+
+func (val customValue) increment() error {
+	return fmt.Errorf("Invalid action for custom value: Increment")
+}
+// ==CLASS customValue END==
+
+
+// ==CLASS genericValue BEGIN==
+type genericValue[T any] struct {
+	defaultValue T
+	value        *T
+	parse        func(string) (T, error)
+}
+
+func newGenericValue[T any](defaultValue T, parse func(string) (T, error)) genericValue[T] {
+	val := genericValue[T]{}
+	val.defaultValue = defaultValue
+	val.value = new(T)
+	val.parse = parse
+
+	val.setDefault()
+
+	return val
+}
+
+func (val genericValue[T]) get() string {
+	return fmt.Sprint(*(val.value))
+}
+
+func (val genericValue[T]) getDefault() string {
+	if reflect.ValueOf(&val.defaultValue).Elem().IsZero() {
+		return ""
+	}
+	return fmt.Sprint(val.defaultValue)
+}
+
+func (val genericValue[T]) typeName() string {
+	return shortTypeName(fmt.Sprintf("%T", val.defaultValue))
+}
+
+func (val genericValue[T]) getChoices() []string {
+	return nil
+}
+
+func (val genericValue[T]) set(value string, name string, shortcut rune) error {
+	parsed, err := val.parse(value)
+	if err != nil {
+		return invalidValue(value, name, shortcut, err.Error())
+	}
+
+	*(val.value) = parsed
+	return nil
+}
+
+func (val genericValue[T]) getRaw() interface{} {
+	return *(val.value)
+}
+
+func (val genericValue[T]) clone() value {
+	return newGenericValue(val.defaultValue, val.parse)
+}
+
+func (val genericValue[T]) setDefault() {
+	*(val.value) = val.defaultValue
+}
+
+func (val genericValue[T]) setTrue() error {
+	return fmt.Errorf("Invalid action for %T: Store True", val.defaultValue)
+}
+
+func (val genericValue[T]) setFalse() error {
+	return fmt.Errorf("Invalid action for %T: Store False", val.defaultValue)
+}
+
+func (val genericValue[T]) setConstant() error {
+	return fmt.Errorf("Invalid action for %T: Store Const", val.defaultValue)
+}
+
+func (val genericValue[T]) increment() error {
+	return fmt.Errorf("Invalid action for %T: Increment", val.defaultValue)
+}
+// ==CLASS genericValue END==
+
+
+// AddValue adds an argument of a custom type, the value given in the
+// command line is passed to val.Set. val must be a pointer that implements
+// ClonableValue, its Clone is used to restore the default of val before
+// each parse and to parse it with ParseArgs.
+func (parser *ArgParser) AddValue(name string, shortcut rune, description string, mandatory bool, val Value, group string) error {
+
+	if val == nil {
+		return fmt.Errorf("Value of argument %s cannot be nil", name)
+	}
+
+	clonable, ok := val.(ClonableValue)
+	if !ok {
+		return fmt.Errorf("Value %T of argument %s does not implement ClonableValue", val, name)
+	}
+
+	if reflect.ValueOf(val).Kind() != reflect.Ptr || reflect.ValueOf(val).IsNil() {
+		return fmt.Errorf("Value %T of argument %s must be a non nil pointer", val, name)
+	}
+
+	if reflect.TypeOf(clonable.Clone()) != reflect.TypeOf(val) {
+		return fmt.Errorf("Clone of the value %T of argument %s must return a %T", val, name, val)
+	}
+
+	arg, err := parser.createArg(name, shortcut, description, mandatory)
+
+	if err != nil {
+		return err
+	}
+
+	arg.action = ActionStoreValue
+	arg.val = newCustomValue(clonable)
+
+	return parser.addArg(arg, group)
+}
+
+func (argGroup *ArgumentsGroup) AddValue(name string, shortcut rune, description string, mandatory bool, val Value) error {
+	return argGroup.parser.AddValue(name, shortcut, description, mandatory, val, argGroup.name)
+}
+
+// Add adds an argument of any type, the value given in the command line is
+// converted by parse. Ex:
+//
+//	ip, err := argparse.Add(parser, "--ip", 'i', "address", false, nil, parseIP, "")
+func Add[T any](parser *ArgParser, name string, shortcut rune, description string, mandatory bool, defaultValue T, parse func(string) (T, error), group string) (*T, error) {
+
+	if parse == nil {
+		return nil, fmt.Errorf("Parse function of argument %s cannot be nil", name)
+	}
+
+	arg, err := parser.createArg(name, shortcut, description, mandatory)
+
+	if err != nil {
+		return nil, err
+	}
+
+	val := newGenericValue(defaultValue, parse)
+
+	arg.action = ActionStoreValue
+	arg.val = val
+
+	err = parser.addArg(arg, group)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return val.value, nil
+}
@@ -0,0 +1,262 @@
+package argparse
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+// semver is a Value that accepts major.minor versions
+type semver struct {
+	major, minor int
+}
+
+func (v *semver) String() string {
+	return fmt.Sprintf("%d.%d", v.major, v.minor)
+}
+
+func (v *semver) Set(value string) error {
+	_, err := fmt.Sscanf(value, "%d.%d", &v.major, &v.minor)
+	if err != nil {
+		return fmt.Errorf("must be major.minor")
+	}
+	return nil
+}
+
+func (v *semver) Clone() Value {
+	c := *v
+	return &c
+}
+
+// tags is a Value that accumulates the tags given, Ex: --tag a --tag b
+type tags []string
+
+func (v *tags) String() string {
+	return strings.Join(*v, ",")
+}
+
+func (v *tags) Set(value string) error {
+	*v = append(*v, value)
+	return nil
+}
+
+func (v *tags) Clone() Value {
+	c := append(tags{}, *v...)
+	return &c
+}
+
+// port is a Value whose default, auto, is not accepted by Set
+type port struct {
+	number int
+}
+
+func (v *port) String() string {
+	if v.number == 0 {
+		return "auto"
+	}
+	return fmt.Sprint(v.number)
+}
+
+func (v *port) Set(value string) error {
+	_, err := fmt.Sscanf(value, "%d", &v.number)
+	return err
+}
+
+func (v *port) Clone() Value {
+	c := *v
+	return &c
+}
+
+// name is a Value that cannot be cloned
+type name struct {
+	value string
+}
+
+func (v *name) String() string {
+	return v.value
+}
+
+func (v *name) Set(value string) error {
+	v.value = value
+	return nil
+}
+
+// badClone is a semver whose Clone returns another type
+type badClone struct {
+	semver
+}
+
+func (v *badClone) Clone() Value {
+	return &v.semver
+}
+
+func TestAddValue(t *testing.T) {
+	parser, err := NewArgParser("prog", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	version := &semver{1, 0}
+	err = parser.AddValue("--version", 'V', "minimum version", false, version, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = parser.Parse([]string{"prog", "-V", "2.5"})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if *version != (semver{2, 5}) {
+		t.Errorf("got version %s, want 2.5", version)
+	}
+
+	err = parser.Parse([]string{"prog"})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if *version != (semver{1, 0}) {
+		t.Errorf("got version %s, want the default 1.0", version)
+	}
+
+	err = parser.Parse([]string{"prog", "--version", "two"})
+	if err == nil || err.Error() != `Invalid value "two" for argument --version[-V], must be major.minor` {
+		t.Errorf("got error %v", err)
+	}
+
+	for value, reason := range map[Value]string{
+		nil:            "cannot be nil",
+		&name{}:        "does not implement ClonableValue",
+		(*semver)(nil): "must be a non nil pointer",
+		&badClone{}:    "Clone of the value *argparse.badClone of argument --invalid must return a *argparse.badClone",
+	} {
+		err := parser.AddValue("--invalid", NOSHORTCUT, "", false, value, "")
+		if err == nil || !strings.Contains(err.Error(), reason) {
+			t.Errorf("%T: got error %v, want %q", value, err, reason)
+		}
+	}
+}
+
+func TestAddValueDefault(t *testing.T) {
+	parser, err := NewArgParser("prog", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	labels := &tags{"main"}
+	err = parser.AddValue("--tag", 't', "", false, labels, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listen := &port{}
+	err = parser.AddValue("--port", NOSHORTCUT, "", false, listen, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// each parse starts from the defaults, tags does not keep the tags of
+	// the previous parse and port is restored although Set rejects auto
+	for _, step := range []struct {
+		args []string
+		tags string
+		port string
+	}{
+		{[]string{"prog", "-t", "a", "-t", "b", "--port", "80"}, "main,a,b", "80"},
+		{[]string{"prog", "-t", "c"}, "main,c", "auto"},
+		{[]string{"prog"}, "main", "auto"},
+		{[]string{"prog", "--port", "8080"}, "main", "8080"},
+	} {
+		err := parser.Parse(step.args)
+		if err != nil {
+			t.Fatalf("%v: unexpected error %s", step.args, err)
+		}
+		if labels.String() != step.tags || listen.String() != step.port {
+			t.Errorf("%v: got tags %s and port %s, want %s and %s", step.args, labels, listen, step.tags, step.port)
+		}
+	}
+}
+
+func TestAdd(t *testing.T) {
+	parser, err := NewArgParser("prog", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ip, err := Add(parser, "--ip", 'i', "address", false, netip.MustParseAddr("127.0.0.1"), netip.ParseAddr, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hosts, err := Add(parser, "host", NOSHORTCUT, "", true, nil, func(value string) ([]string, error) {
+		return strings.Split(value, ","), nil
+	}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = parser.Parse([]string{"prog", "--ip", "::1", "a,b"})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if ip.String() != "::1" || len(*hosts) != 2 {
+		t.Errorf("got ip=%s hosts=%v", ip, *hosts)
+	}
+
+	err = parser.Parse([]string{"prog", "-i", "300.0.0.1", "a"})
+	var invalid *InvalidValueError
+	if !errors.As(err, &invalid) || invalid.Argument != "--ip" {
+		t.Errorf("got error %v, want an InvalidValueError", err)
+	}
+
+	err = parser.Parse([]string{"prog", "a"})
+	if err != nil || ip.String() != "127.0.0.1" {
+		t.Errorf("got error %v and ip %s, want the default", err, ip)
+	}
+
+	if _, err := Add[int](parser, "--n", NOSHORTCUT, "", false, 0, nil, ""); err == nil {
+		t.Errorf("expected an error for a nil parse function")
+	}
+}
+
+func TestHelpDefaults(t *testing.T) {
+	parser, err := NewArgParser("prog", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = parser.AddInt("--retries", NOSHORTCUT, "times to retry", false, ActionStoreValue, 3, 0, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = parser.AddString("--name", NOSHORTCUT, "user name", false, ActionStoreValue, "guest", "", nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = parser.AddFloat("--ratio", NOSHORTCUT, "ratio", false, ActionStoreValue, 0, 0, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = parser.AddBool("--color", NOSHORTCUT, "use colors", false, ActionStoreFalse, true, false, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Add(parser, "--ip", NOSHORTCUT, "address", false, netip.MustParseAddr("10.0.0.1"), netip.ParseAddr, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = parser.AddValue("--version", NOSHORTCUT, "minimum version", false, &semver{1, 2}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	help := parser.Help()
+	for _, line := range []string{
+		"times to retry (default: 3)\n",
+		"user name (default: guest)\n",
+		"address (default: 10.0.0.1)\n",
+		"minimum version (default: 1.2)\n",
+		// zero defaults and flags do not show it
+		"ratio\n",
+		"use colors\n",
+	} {
+		if !strings.Contains(help, line) {
+			t.Errorf("%q is not in the help:\n%s", line, help)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package argparse
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// checkGolden compares got with the file testdata/name, which is rewritten
+// with -update
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+
+	if *update {
+		err := os.MkdirAll("testdata", 0o755)
+		if err == nil {
+			err = os.WriteFile(path, got, 0o644)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%s (run go test -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s (run go test -update after checking it):\n%s", path, got)
+	}
+}
+
+// describeParser writes the parser and its subparsers as seen through the
+// exported accessors, one line per parser, group and argument
+func describeParser(parser *ArgParser, out *bytes.Buffer) {
+	parent := "-"
+	if parser.Parent() != nil {
+		parent = parser.Parent().Name()
+	}
+	fmt.Fprintf(out, "parser %q name=%s parent=%s description=%q\n", parser.Path(), parser.Name(), parent, parser.Description())
+
+	describe := func(kind string, arg *Argument) {
+		shortcut := "-"
+		if arg.Shortcut() != NOSHORTCUT {
+			shortcut = string(arg.Shortcut())
+		}
+		fmt.Fprintf(out, "  %s %s shortcut=%s action=%d mandatory=%t positional=%t default=%q choices=%s description=%q\n",
+			kind, arg.Name(), shortcut, arg.Action(), arg.Mandatory(), arg.Positional(), arg.Default(), strings.Join(arg.Choices(), ","), arg.Description())
+	}
+	for _, arg := range parser.Arguments() {
+		describe("argument", arg)
+	}
+	for _, arg := range parser.Positionals() {
+		describe("positional", arg)
+	}
+	for _, argGroup := range parser.Groups() {
+		names := []string{}
+		for _, arg := range argGroup.Arguments() {
+			names = append(names, arg.Name())
+		}
+		fmt.Fprintf(out, "  group %s required=%t exclusive=%t arguments=%s description=%q\n", argGroup.Name(), argGroup.Required(), argGroup.Exclusive(), strings.Join(names, ","), argGroup.Description())
+	}
+
+	for _, subparser := range parser.Subparsers() {
+		describeParser(subparser, out)
+	}
+}
+
+func TestIntrospectionGolden(t *testing.T) {
+	parser, err := NewArgParser("tool", "Manages the remotes of a repository.", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	must := func(_ interface{}, err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	format, err := parser.AddArgumentsGroup("format", "output format", false, true)
+	must(format, err)
+	must(parser.AddBool("--verbose", 'v', "print more details", false, ActionStoreTrue, false, false, nil, ""))
+	must(format.AddStringChoices("--output", 'o', "output as", false, ActionStoreValue, "text", "", []string{"json", "text", "yaml"}, nil))
+	must(format.AddBool("--raw", NOSHORTCUT, "raw output", false, ActionStoreTrue, false, false, nil))
+	must(parser.AddInt("--level", 'l', "", false, ActionIncrement, 0, 0, nil, ""))
+
+	remote, err := parser.AddSubparser("remote", "Manage remotes", true)
+	must(remote, err)
+	must(remote.AddInt("--timeout", NOSHORTCUT, "seconds to wait", false, ActionStoreValue, 30, 0, nil, ""))
+
+	add, err := remote.AddSubparser("add", "Add a remote", true)
+	must(add, err)
+	must(add.AddString("name", NOSHORTCUT, "remote name", true, ActionStoreValue, "", "", nil, ""))
+	must(add.AddStringChoices("kind", NOSHORTCUT, "version control", true, ActionStoreValue, "", "", []string{"git", "hg"}, nil, ""))
+	must(add.AddStringSlice("--tag", 't', "tags of the remote", false, nil, nil, ""))
+
+	status, err := parser.AddSubparser("status", "Show the status", false)
+	must(status, err)
+	must(status.AddBool("--short", 's', "one line per file", false, ActionStoreTrue, false, false, nil, ""))
+
+	var out bytes.Buffer
+	describeParser(parser, &out)
+	checkGolden(t, "introspection.txt", out.Bytes())
+}
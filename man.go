@@ -0,0 +1,217 @@
+package argparse
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// roffEscape escapes text for roff, so backslashes and dashes are printed
+// as they are and lines do not begin with a control character
+func roffEscape(text string) string {
+	text = strings.ReplaceAll(text, "\\", "\\e")
+	text = strings.ReplaceAll(text, "-", "\\-")
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = "\\&" + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// pageName returns the name of the man page or doc file of the parser, Ex:
+// tool-remote
+func (parser *ArgParser) pageName() string {
+	return strings.ReplaceAll(parser.path(), " ", "-")
+}
+
+// manTerm returns the names and the value of the argument in roff, Ex:
+// \fB\-\-num\fR, \fB\-n\fR \fINUM\fR
+func (arg Argument) manTerm() string {
+	if arg.positional {
+		term := "\\fI" + roffEscape(arg.name) + "\\fR"
+		if choices := arg.val.getChoices(); len(choices) > 0 {
+			term += " " + roffEscape(arg.metavar())
+		}
+		return term
+	}
+
+	names := []string{}
+	for _, name := range arg.flagNames() {
+		names = append(names, "\\fB" + roffEscape(name) + "\\fR")
+	}
+
+	term := strings.Join(names, ", ")
+	if arg.takesValue() {
+		term += " \\fI" + roffEscape(arg.metavar()) + "\\fR"
+	}
+	return term
+}
+
+func (arg Argument) writeMan(page *bytes.Buffer) {
+	page.WriteString(".TP\n" + arg.manTerm() + "\n")
+	if description := arg.helpDescription(); description != "" {
+		page.WriteString(roffEscape(description) + "\n")
+	}
+}
+
+// manHeading returns the heading of a section of the page, the sections of
+// a subcommand in COMMANDS are bold paragraphs because roff only has two
+// levels of headings
+func manHeading(title string, level int) string {
+	switch level {
+		case 0:
+			return fmt.Sprintf(".SH %s\n", strings.ToUpper(title))
+		case 1:
+			return fmt.Sprintf(".SS \"%s\"\n", roffEscape(title))
+	}
+	return fmt.Sprintf(".PP\n\\fB%s\\fR\n", roffEscape(title))
+}
+
+// writeManArguments writes the positional arguments and the options of the
+// parser, grouped by arguments group, with headings of the given level
+func (parser *ArgParser) writeManArguments(page *bytes.Buffer, level int) {
+	if len(parser.posArguments) > 0 {
+		page.WriteString(manHeading("Positional arguments", level))
+		for _, arg := range parser.posArguments {
+			arg.writeMan(page)
+		}
+	}
+
+	ungrouped := []*Argument{}
+	for _, arg := range parser.Arguments() {
+		if _, ok := parser.getArgumentGroup(arg); !ok {
+			ungrouped = append(ungrouped, arg)
+		}
+	}
+
+	if len(ungrouped) == 0 && len(parser.groups) == 0 {
+		return
+	}
+
+	page.WriteString(manHeading("Options", level))
+	for _, arg := range ungrouped {
+		arg.writeMan(page)
+	}
+
+	for _, argGroup := range parser.Groups() {
+		page.WriteString(manHeading(argGroup.name, level + 1))
+		if argGroup.description != "" {
+			page.WriteString(roffEscape(argGroup.description) + "\n")
+		}
+		if constraint := argGroup.constraint(); constraint != "" {
+			page.WriteString(".PP\n" + roffEscape(constraint) + "\n")
+		}
+		for _, arg := range argGroup.arguments {
+			arg.writeMan(page)
+		}
+	}
+}
+
+// writeManSynopsis writes the usage of the parser without the Usage: prefix
+func (parser *ArgParser) writeManSynopsis(page *bytes.Buffer) {
+	usage := strings.TrimSpace(strings.TrimPrefix(parser.Usage(), "Usage: "))
+	page.WriteString(".nf\n" + roffEscape(usage) + "\n.fi\n")
+}
+
+// writeManPage writes the page of the parser, with all its subcommands in
+// COMMANDS or, if pages is true, with references to their own pages
+func (parser *ArgParser) writeManPage(w io.Writer, pages bool) error {
+	var page bytes.Buffer
+
+	root := parser
+	for root.parent != nil {
+		root = root.parent
+	}
+
+	page.WriteString(fmt.Sprintf(".TH \"%s\" \"1\" \"\" \"%s\" \"User Commands\"\n", roffEscape(strings.ToUpper(parser.pageName())), roffEscape(root.name)))
+
+	page.WriteString(manHeading("Name", 0))
+	if parser.description != "" {
+		page.WriteString(roffEscape(parser.pageName()) + " \\- " + roffEscape(parser.description) + "\n")
+	} else {
+		page.WriteString(roffEscape(parser.pageName()) + "\n")
+	}
+
+	page.WriteString(manHeading("Synopsis", 0))
+	parser.writeManSynopsis(&page)
+
+	if parser.description != "" {
+		page.WriteString(manHeading("Description", 0))
+		page.WriteString(roffEscape(parser.description) + "\n")
+	}
+
+	parser.writeManArguments(&page, 0)
+
+	if len(parser.subparsers) > 0 {
+		page.WriteString(manHeading("Commands", 0))
+
+		if pages {
+			for _, subparser := range parser.Subparsers() {
+				page.WriteString(fmt.Sprintf(".TP\n\\fB%s\\fR(1)\n", roffEscape(subparser.pageName())))
+				if subparser.description != "" {
+					page.WriteString(roffEscape(subparser.description) + "\n")
+				}
+			}
+		} else {
+			for _, subparser := range parser.allParsers()[1:] {
+				page.WriteString(manHeading(subparser.path(), 1))
+				if subparser.description != "" {
+					page.WriteString(roffEscape(subparser.description) + "\n")
+				}
+				page.WriteString(".PP\n")
+				subparser.writeManSynopsis(&page)
+				subparser.writeManArguments(&page, 2)
+			}
+		}
+	}
+
+	if pages && (parser.parent != nil || len(parser.subparsers) > 0) {
+		references := []string{}
+		if parser.parent != nil {
+			references = append(references, fmt.Sprintf("\\fB%s\\fR(1)", roffEscape(parser.parent.pageName())))
+		}
+		for _, subparser := range parser.Subparsers() {
+			references = append(references, fmt.Sprintf("\\fB%s\\fR(1)", roffEscape(subparser.pageName())))
+		}
+		page.WriteString(manHeading("See also", 0))
+		page.WriteString(strings.Join(references, ", ") + "\n")
+	}
+
+	_, err := w.Write(page.Bytes())
+	return err
+}
+
+// WriteManPage writes a section 1 man page in roff for the parser, the
+// subcommands are described in its COMMANDS section. Ex:
+//
+//	parser.WriteManPage(os.Stdout) // tool.1, see it with: man ./tool.1
+func (parser *ArgParser) WriteManPage(w io.Writer) error {
+	return parser.writeManPage(w, false)
+}
+
+// WriteManPages writes a man page for the parser and one for each of its
+// subcommands in dir, named by their path, Ex: tool.1 and tool-remote.1
+func (parser *ArgParser) WriteManPages(dir string) error {
+	for _, p := range parser.allParsers() {
+		file, err := os.Create(filepath.Join(dir, p.pageName() + ".1"))
+		if err != nil {
+			return err
+		}
+
+		err = p.writeManPage(file, true)
+		closeErr := file.Close()
+		if err != nil {
+			return err
+		}
+		if closeErr != nil {
+			return closeErr
+		}
+	}
+	return nil
+}
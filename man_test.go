@@ -0,0 +1,79 @@
+package argparse
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestRoffEscape(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"plain text", "plain text"},
+		{"--dry-run", "\\-\\-dry\\-run"},
+		{"C:\\dir", "C:\\edir"},
+		{".starts with a request", "\\&.starts with a request"},
+		{"first\n'quoted line", "first\n\\&'quoted line"},
+		{"a dot. in the middle", "a dot. in the middle"},
+	}
+
+	for _, test := range tests {
+		if got := roffEscape(test.text); got != test.want {
+			t.Errorf("roffEscape(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestWriteManPage(t *testing.T) {
+	var page bytes.Buffer
+	err := newToolParser(t).WriteManPage(&page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "tool.1", page.Bytes())
+}
+
+func TestWriteManPages(t *testing.T) {
+	dir := t.TempDir()
+	err := newToolParser(t).WriteManPages(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+
+	want := []string{"tool-remote-add.1", "tool-remote.1", "tool-status.1", "tool.1"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("pages = %v, want %v", names, want)
+	}
+
+	// the root page refers to its subcommands instead of describing them
+	for _, name := range names {
+		page, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkGolden(t, "pages_"+name, page)
+	}
+}
+
+func TestWriteManPagesMissingDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "missing")
+	err := newToolParser(t).WriteManPages(dir)
+	if !os.IsNotExist(err) {
+		t.Errorf("WriteManPages in a missing directory: got %v, want a not exist error", err)
+	}
+}
@@ -0,0 +1,189 @@
+package argparse
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// markdownCell escapes text for a cell of a Markdown table
+func markdownCell(text string) string {
+	text = strings.ReplaceAll(text, "|", "\\|")
+	return strings.ReplaceAll(text, "\n", "<br>")
+}
+
+// markdownCode returns text as inline code, or "" if it is empty
+func markdownCode(text string) string {
+	if text == "" {
+		return ""
+	}
+	return "`" + markdownCell(text) + "`"
+}
+
+// markdownAnchor returns the anchor of a heading as generated by GitHub,
+// Ex: tool remote -> #tool-remote
+func markdownAnchor(heading string) string {
+	var anchor bytes.Buffer
+	for _, char := range strings.ToLower(heading) {
+		if isLetter(char) || isDigit(char) || char == '-' || char == '_' {
+			anchor.WriteRune(char)
+		} else if char == ' ' {
+			anchor.WriteRune('-')
+		}
+	}
+	return "#" + anchor.String()
+}
+
+func markdownHeading(title string, level int) string {
+	return fmt.Sprintf("%s %s\n\n", strings.Repeat("#", level), title)
+}
+
+// markdownLink returns the link to the docs of the parser, to its file or
+// to its heading in the same file
+func (parser *ArgParser) markdownLink(files bool) string {
+	if files {
+		return parser.pageName() + ".md"
+	}
+	return markdownAnchor(parser.path())
+}
+
+func (arg Argument) markdownChoices() string {
+	choices := []string{}
+	for _, choice := range arg.val.getChoices() {
+		choices = append(choices, markdownCode(choice))
+	}
+	return strings.Join(choices, ", ")
+}
+
+// writeMarkdownArguments writes the tables of the positional arguments and
+// the options of the parser, grouped by arguments group
+func (parser *ArgParser) writeMarkdownArguments(doc *bytes.Buffer, level int) {
+	if len(parser.posArguments) > 0 {
+		doc.WriteString(markdownHeading("Positional arguments", level))
+		doc.WriteString("| Name | Type | Choices | Description |\n")
+		doc.WriteString("| --- | --- | --- | --- |\n")
+		for _, arg := range parser.posArguments {
+			doc.WriteString(fmt.Sprintf("| %s | %s | %s | %s |\n", markdownCode(arg.name), markdownCode(arg.Type()), arg.markdownChoices(), markdownCell(arg.description)))
+		}
+		doc.WriteString("\n")
+	}
+
+	ungrouped := []*Argument{}
+	for _, arg := range parser.Arguments() {
+		if _, ok := parser.getArgumentGroup(arg); !ok {
+			ungrouped = append(ungrouped, arg)
+		}
+	}
+
+	if len(ungrouped) == 0 && len(parser.groups) == 0 {
+		return
+	}
+
+	doc.WriteString(markdownHeading("Options", level))
+	writeMarkdownOptions(doc, ungrouped)
+
+	for _, argGroup := range parser.Groups() {
+		doc.WriteString(markdownHeading(argGroup.name, level + 1))
+		if argGroup.description != "" {
+			doc.WriteString(argGroup.description + "\n\n")
+		}
+		if constraint := argGroup.constraint(); constraint != "" {
+			doc.WriteString("*" + constraint + "*\n\n")
+		}
+		writeMarkdownOptions(doc, argGroup.arguments)
+	}
+}
+
+func writeMarkdownOptions(doc *bytes.Buffer, args []*Argument) {
+	if len(args) == 0 {
+		return
+	}
+
+	doc.WriteString("| Name | Shortcut | Type | Default | Required | Env | Choices | Description |\n")
+	doc.WriteString("| --- | --- | --- | --- | --- | --- | --- | --- |\n")
+	for _, arg := range args {
+		shortcut := ""
+		if arg.shortcut != NOSHORTCUT {
+			shortcut = string(pARAMPREFIX) + string(arg.shortcut)
+		}
+
+		argType := ""
+		def := ""
+		if arg.takesValue() {
+			argType = arg.Type()
+			def = arg.val.getDefault()
+		}
+
+		required := ""
+		if arg.mandatory {
+			required = "yes"
+		}
+
+		doc.WriteString(fmt.Sprintf("| %s | %s | %s | %s | %s | %s | %s | %s |\n", markdownCode(arg.name), markdownCode(shortcut), markdownCode(argType), markdownCode(def), required, markdownCode(arg.envName()), arg.markdownChoices(), markdownCell(arg.description)))
+	}
+	doc.WriteString("\n")
+}
+
+// writeMarkdownParser writes the docs of the parser, with links to its
+// subcommands and, in files, to its parent
+func (parser *ArgParser) writeMarkdownParser(doc *bytes.Buffer, level int, files bool) {
+	doc.WriteString(markdownHeading(parser.path(), level))
+
+	if files && parser.parent != nil {
+		doc.WriteString(fmt.Sprintf("Subcommand of [%s](%s).\n\n", parser.parent.path(), parser.parent.markdownLink(files)))
+	}
+
+	if parser.description != "" {
+		doc.WriteString(parser.description + "\n\n")
+	}
+
+	doc.WriteString("```\n" + strings.TrimSpace(parser.Usage()) + "\n```\n\n")
+
+	parser.writeMarkdownArguments(doc, level + 1)
+
+	if len(parser.subparsers) > 0 {
+		doc.WriteString(markdownHeading("Commands", level + 1))
+		doc.WriteString("| Command | Description |\n")
+		doc.WriteString("| --- | --- |\n")
+		for _, subparser := range parser.Subparsers() {
+			doc.WriteString(fmt.Sprintf("| [%s](%s) | %s |\n", subparser.name, subparser.markdownLink(files), markdownCell(subparser.description)))
+		}
+		doc.WriteString("\n")
+	}
+}
+
+// WriteMarkdown writes a Markdown reference of the parser and its
+// subcommands, each one in its own section
+func (parser *ArgParser) WriteMarkdown(w io.Writer) error {
+	var doc bytes.Buffer
+
+	for i, p := range parser.allParsers() {
+		level := 2
+		if i == 0 {
+			level = 1
+		}
+		p.writeMarkdownParser(&doc, level, false)
+	}
+
+	_, err := w.Write(doc.Bytes())
+	return err
+}
+
+// WriteMarkdownFiles writes a Markdown reference of the parser and one for
+// each of its subcommands in dir, named by their path and linked to each
+// other, Ex: tool.md and tool-remote.md
+func (parser *ArgParser) WriteMarkdownFiles(dir string) error {
+	for _, p := range parser.allParsers() {
+		var doc bytes.Buffer
+		p.writeMarkdownParser(&doc, 1, true)
+
+		err := os.WriteFile(filepath.Join(dir, p.pageName() + ".md"), doc.Bytes(), 0644)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package argparse
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMarkdownCell(t *testing.T) {
+	if got := markdownCell("a|b\nc"); got != "a\\|b<br>c" {
+		t.Errorf("markdownCell = %q", got)
+	}
+	if got := markdownCode(""); got != "" {
+		t.Errorf("markdownCode of an empty text = %q, want it empty", got)
+	}
+	if got := markdownCode("--dry-run"); got != "`--dry-run`" {
+		t.Errorf("markdownCode = %q", got)
+	}
+}
+
+func TestMarkdownAnchor(t *testing.T) {
+	tests := map[string]string{
+		"tool":            "#tool",
+		"tool remote add": "#tool-remote-add",
+		"Tool Remote":     "#tool-remote",
+		"my_tool v2.0":    "#my_tool-v20",
+	}
+
+	for heading, want := range tests {
+		if got := markdownAnchor(heading); got != want {
+			t.Errorf("markdownAnchor(%q) = %q, want %q", heading, got, want)
+		}
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var doc bytes.Buffer
+	err := newToolParser(t).WriteMarkdown(&doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "tool.md", doc.Bytes())
+}
+
+func TestWriteMarkdownFiles(t *testing.T) {
+	dir := t.TempDir()
+	err := newToolParser(t).WriteMarkdownFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"tool.md", "tool-remote.md", "tool-remote-add.md", "tool-status.md"} {
+		doc, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkGolden(t, "files_"+name, doc)
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 4 {
+		t.Errorf("WriteMarkdownFiles wrote %d files, want 4", len(matches))
+	}
+}
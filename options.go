@@ -0,0 +1,542 @@
+package argparse
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+/*
+Functional options to define arguments without giving every parameter of
+the AddX functions, Ex:
+
+	name, err := parser.String("--name", Short('n'), Help("user name"), Default("x"))
+*/
+
+// ArgOption configures an argument defined with the functional options API
+type ArgOption func(*argOptions)
+
+type argOptions struct {
+	shortcut         rune
+	description      string
+	mandatory        bool
+	action           int // 0 means the default action of the type
+	defaultValue     interface{}
+	constValue       interface{}
+	check            interface{}
+	choices          []interface{}
+	group            string
+	unit             time.Duration
+	separator        string
+	rejectDuplicates bool
+	env              string
+	completion       CompletionFunc
+}
+
+func newArgOptions(opts []ArgOption) *argOptions {
+	o := new(argOptions)
+	o.shortcut = NOSHORTCUT
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// checkOptions checks the environment variable and the completion function
+// before the argument is added with action, so an invalid option does not
+// leave the argument defined
+func (o *argOptions) checkOptions(name string, action int) error {
+	if o.env != "" && isPositionalName(name) {
+		return fmt.Errorf("Positional argument %s cannot be read from environment", name)
+	}
+	// mandatory arguments always take a value
+	if o.completion != nil && !o.mandatory && action != ActionStoreValue && action != ActionAppend {
+		return fmt.Errorf("Argument %s does not take a value", name)
+	}
+	return nil
+}
+
+// applyOptions sets the environment variable and the completion function
+// of the argument just added, they are checked by checkOptions
+func (parser *ArgParser) applyOptions(o *argOptions, err error) error {
+	if err != nil {
+		return err
+	}
+	arg := parser.arguments[len(parser.arguments)-1]
+	if o.env != "" {
+		arg.env = o.env
+	}
+	if o.completion != nil {
+		arg.completion = o.completion
+	}
+	return nil
+}
+
+// checkAppend checks the action of slice and map arguments, they only
+// support ActionAppend
+func (o *argOptions) checkAppend(name string) error {
+	if o.action != 0 && o.action != ActionAppend {
+		return fmt.Errorf("Argument %s only supports ActionAppend", name)
+	}
+	return nil
+}
+
+func (o *argOptions) actionOr(action int) int {
+	if o.action == 0 {
+		return action
+	}
+	return o.action
+}
+
+// Short sets the shortcut of the argument, Ex: Short('n') for -n
+func Short(shortcut rune) ArgOption {
+	return func(o *argOptions) { o.shortcut = shortcut }
+}
+
+// Help sets the description of the argument
+func Help(description string) ArgOption {
+	return func(o *argOptions) { o.description = description }
+}
+
+// Default sets the default value, it must be of the type of the argument
+func Default(value interface{}) ArgOption {
+	return func(o *argOptions) { o.defaultValue = value }
+}
+
+// Const sets the value stored by ActionStoreConst
+func Const(value interface{}) ArgOption {
+	return func(o *argOptions) { o.constValue = value }
+}
+
+// Required makes the argument mandatory
+func Required() ArgOption {
+	return func(o *argOptions) { o.mandatory = true }
+}
+
+// InGroup adds the argument to an arguments group of the parser
+func InGroup(group string) ArgOption {
+	return func(o *argOptions) { o.group = group }
+}
+
+// Check sets the check function, Ex: func(int) bool for Int arguments
+func Check(fn interface{}) ArgOption {
+	return func(o *argOptions) { o.check = fn }
+}
+
+// Action sets the action of the argument, by default ActionStoreValue
+// (ActionStoreTrue for Bool). Slices and maps only support ActionAppend.
+func Action(action int) ArgOption {
+	return func(o *argOptions) { o.action = action }
+}
+
+// Choices restricts the values of String and Int arguments, a Check
+// function must also be met
+func Choices(values ...interface{}) ArgOption {
+	return func(o *argOptions) { o.choices = values }
+}
+
+// Unit sets the unit of bare numbers of Duration arguments
+func Unit(unit time.Duration) ArgOption {
+	return func(o *argOptions) { o.unit = unit }
+}
+
+// Separator sets the key/value separator of map arguments, by default =
+func Separator(separator string) ArgOption {
+	return func(o *argOptions) { o.separator = separator }
+}
+
+// RejectDuplicates makes map arguments fail if a key is given twice
+func RejectDuplicates() ArgOption {
+	return func(o *argOptions) { o.rejectDuplicates = true }
+}
+
+// Env sets the environment variable read when the argument is not given
+func Env(name string) ArgOption {
+	return func(o *argOptions) { o.env = name }
+}
+
+// Completion sets the function that completes the value of the argument,
+// it is only valid in arguments that take a value, see SetCompletionFunc
+func Completion(fn CompletionFunc) ArgOption {
+	return func(o *argOptions) { o.completion = fn }
+}
+
+// optionValue converts the value of an option to the type of the argument,
+// nil is the zero value
+func optionValue[T any](value interface{}, name string, option string) (T, error) {
+	var result T
+
+	if value == nil {
+		return result, nil
+	}
+
+	if v, ok := value.(T); ok {
+		return v, nil
+	}
+
+	// allow Default(1) in float arguments
+	if f, ok := any(&result).(*float64); ok {
+		if i, ok := value.(int); ok {
+			*f = float64(i)
+			return result, nil
+		}
+	}
+
+	return result, fmt.Errorf("%s value of argument %s must be %T, not %T", option, name, result, value)
+}
+
+// optionCheck converts the check option to a check function, named types
+// as IntArgCheckFunc are accepted
+func optionCheck[T any](fn interface{}, name string) (func(T) bool, error) {
+	if fn == nil {
+		return nil, nil
+	}
+
+	target := reflect.TypeOf((func(T) bool)(nil))
+	value := reflect.ValueOf(fn)
+	if !value.Type().ConvertibleTo(target) {
+		return nil, fmt.Errorf("Check function of argument %s must be %s, not %T", name, target, fn)
+	}
+
+	return value.Convert(target).Interface().(func(T) bool), nil
+}
+
+func optionChoices[T any](choices []interface{}, name string) ([]T, error) {
+	if choices == nil {
+		return nil, nil
+	}
+
+	values := make([]T, 0, len(choices))
+	for _, choice := range choices {
+		value, ok := choice.(T)
+		if !ok {
+			var zero T
+			return nil, fmt.Errorf("Choices of argument %s must be %T, not %T", name, zero, choice)
+		}
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+// Int defines an int argument, see AddInt
+func (parser *ArgParser) Int(name string, opts ...ArgOption) (*int, error) {
+	o := newArgOptions(opts)
+
+	defaultValue, err := optionValue[int](o.defaultValue, name, "Default")
+	if err != nil {
+		return nil, err
+	}
+	constValue, err := optionValue[int](o.constValue, name, "Const")
+	if err != nil {
+		return nil, err
+	}
+	check, err := optionCheck[int](o.check, name)
+	if err != nil {
+		return nil, err
+	}
+	choices, err := optionChoices[int](o.choices, name)
+	if err != nil {
+		return nil, err
+	}
+
+	err = o.checkOptions(name, o.actionOr(ActionStoreValue))
+	if err != nil {
+		return nil, err
+	}
+
+	if choices != nil {
+		value, err := parser.AddIntChoices(name, o.shortcut, o.description, o.mandatory, o.actionOr(ActionStoreValue), defaultValue, constValue, choices, check, o.group)
+		return value, parser.applyOptions(o, err)
+	}
+	value, err := parser.AddInt(name, o.shortcut, o.description, o.mandatory, o.actionOr(ActionStoreValue), defaultValue, constValue, check, o.group)
+	return value, parser.applyOptions(o, err)
+}
+
+// String defines a string argument, see AddString
+func (parser *ArgParser) String(name string, opts ...ArgOption) (*string, error) {
+	o := newArgOptions(opts)
+
+	defaultValue, err := optionValue[string](o.defaultValue, name, "Default")
+	if err != nil {
+		return nil, err
+	}
+	constValue, err := optionValue[string](o.constValue, name, "Const")
+	if err != nil {
+		return nil, err
+	}
+	check, err := optionCheck[string](o.check, name)
+	if err != nil {
+		return nil, err
+	}
+	choices, err := optionChoices[string](o.choices, name)
+	if err != nil {
+		return nil, err
+	}
+
+	err = o.checkOptions(name, o.actionOr(ActionStoreValue))
+	if err != nil {
+		return nil, err
+	}
+
+	if choices != nil {
+		value, err := parser.AddStringChoices(name, o.shortcut, o.description, o.mandatory, o.actionOr(ActionStoreValue), defaultValue, constValue, choices, check, o.group)
+		return value, parser.applyOptions(o, err)
+	}
+	value, err := parser.AddString(name, o.shortcut, o.description, o.mandatory, o.actionOr(ActionStoreValue), defaultValue, constValue, check, o.group)
+	return value, parser.applyOptions(o, err)
+}
+
+// Bool defines a bool argument, by default with ActionStoreTrue, or with
+// ActionStoreFalse if Default(true) is given, see AddBool
+func (parser *ArgParser) Bool(name string, opts ...ArgOption) (*bool, error) {
+	o := newArgOptions(opts)
+
+	defaultValue, err := optionValue[bool](o.defaultValue, name, "Default")
+	if err != nil {
+		return nil, err
+	}
+
+	// the flag stores the opposite of the default, mandatory arguments take
+	// a value
+	action := ActionStoreTrue
+	if defaultValue {
+		action = ActionStoreFalse
+	}
+	action = o.actionOr(action)
+
+	if !o.mandatory && ((action == ActionStoreTrue && defaultValue) || (action == ActionStoreFalse && o.defaultValue != nil && !defaultValue)) {
+		return nil, fmt.Errorf("Default value %t of argument %s is the value stored by its action", defaultValue, name)
+	}
+	constValue, err := optionValue[bool](o.constValue, name, "Const")
+	if err != nil {
+		return nil, err
+	}
+	check, err := optionCheck[bool](o.check, name)
+	if err != nil {
+		return nil, err
+	}
+
+	err = o.checkOptions(name, action)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := parser.AddBool(name, o.shortcut, o.description, o.mandatory, action, defaultValue, constValue, check, o.group)
+	return value, parser.applyOptions(o, err)
+}
+
+// Float defines a float64 argument, see AddFloat
+func (parser *ArgParser) Float(name string, opts ...ArgOption) (*float64, error) {
+	o := newArgOptions(opts)
+
+	defaultValue, err := optionValue[float64](o.defaultValue, name, "Default")
+	if err != nil {
+		return nil, err
+	}
+	constValue, err := optionValue[float64](o.constValue, name, "Const")
+	if err != nil {
+		return nil, err
+	}
+	check, err := optionCheck[float64](o.check, name)
+	if err != nil {
+		return nil, err
+	}
+
+	err = o.checkOptions(name, o.actionOr(ActionStoreValue))
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := parser.AddFloat(name, o.shortcut, o.description, o.mandatory, o.actionOr(ActionStoreValue), defaultValue, constValue, check, o.group)
+	return value, parser.applyOptions(o, err)
+}
+
+// Duration defines a time.Duration argument, see AddDuration
+func (parser *ArgParser) Duration(name string, opts ...ArgOption) (*time.Duration, error) {
+	o := newArgOptions(opts)
+
+	defaultValue, err := optionValue[time.Duration](o.defaultValue, name, "Default")
+	if err != nil {
+		return nil, err
+	}
+	constValue, err := optionValue[time.Duration](o.constValue, name, "Const")
+	if err != nil {
+		return nil, err
+	}
+	check, err := optionCheck[time.Duration](o.check, name)
+	if err != nil {
+		return nil, err
+	}
+
+	err = o.checkOptions(name, o.actionOr(ActionStoreValue))
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := parser.AddDuration(name, o.shortcut, o.description, o.mandatory, o.actionOr(ActionStoreValue), defaultValue, constValue, o.unit, check, o.group)
+	return value, parser.applyOptions(o, err)
+}
+
+// StringSlice defines a string slice argument, see AddStringSlice
+func (parser *ArgParser) StringSlice(name string, opts ...ArgOption) (*[]string, error) {
+	o := newArgOptions(opts)
+
+	err := o.checkAppend(name)
+	if err != nil {
+		return nil, err
+	}
+
+	defaultValue, err := optionValue[[]string](o.defaultValue, name, "Default")
+	if err != nil {
+		return nil, err
+	}
+	check, err := optionCheck[string](o.check, name)
+	if err != nil {
+		return nil, err
+	}
+
+	err = o.checkOptions(name, ActionAppend)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := parser.AddStringSlice(name, o.shortcut, o.description, o.mandatory, defaultValue, check, o.group)
+	return value, parser.applyOptions(o, err)
+}
+
+// IntSlice defines an int slice argument, see AddIntSlice
+func (parser *ArgParser) IntSlice(name string, opts ...ArgOption) (*[]int, error) {
+	o := newArgOptions(opts)
+
+	err := o.checkAppend(name)
+	if err != nil {
+		return nil, err
+	}
+
+	defaultValue, err := optionValue[[]int](o.defaultValue, name, "Default")
+	if err != nil {
+		return nil, err
+	}
+	check, err := optionCheck[int](o.check, name)
+	if err != nil {
+		return nil, err
+	}
+
+	err = o.checkOptions(name, ActionAppend)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := parser.AddIntSlice(name, o.shortcut, o.description, o.mandatory, defaultValue, check, o.group)
+	return value, parser.applyOptions(o, err)
+}
+
+// StringMap defines a string map argument, see AddStringMap
+func (parser *ArgParser) StringMap(name string, opts ...ArgOption) (*map[string]string, error) {
+	o := newArgOptions(opts)
+
+	err := o.checkAppend(name)
+	if err != nil {
+		return nil, err
+	}
+
+	defaultValue, err := optionValue[map[string]string](o.defaultValue, name, "Default")
+	if err != nil {
+		return nil, err
+	}
+
+	var check StringMapArgCheckFunc
+	if o.check != nil {
+		fn, ok := o.check.(func(string, string) bool)
+		if !ok {
+			fn, ok = o.check.(StringMapArgCheckFunc)
+		}
+		if !ok {
+			return nil, fmt.Errorf("Check function of argument %s must be func(string, string) bool, not %T", name, o.check)
+		}
+		check = fn
+	}
+
+	err = o.checkOptions(name, ActionAppend)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := parser.AddStringMap(name, o.shortcut, o.description, o.mandatory, o.separator, o.rejectDuplicates, defaultValue, check, o.group)
+	return value, parser.applyOptions(o, err)
+}
+
+// IntMap defines an int map argument, see AddIntMap
+func (parser *ArgParser) IntMap(name string, opts ...ArgOption) (*map[string]int, error) {
+	o := newArgOptions(opts)
+
+	err := o.checkAppend(name)
+	if err != nil {
+		return nil, err
+	}
+
+	defaultValue, err := optionValue[map[string]int](o.defaultValue, name, "Default")
+	if err != nil {
+		return nil, err
+	}
+
+	var check IntMapArgCheckFunc
+	if o.check != nil {
+		fn, ok := o.check.(func(string, int) bool)
+		if !ok {
+			fn, ok = o.check.(IntMapArgCheckFunc)
+		}
+		if !ok {
+			return nil, fmt.Errorf("Check function of argument %s must be func(string, int) bool, not %T", name, o.check)
+		}
+		check = fn
+	}
+
+	err = o.checkOptions(name, ActionAppend)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := parser.AddIntMap(name, o.shortcut, o.description, o.mandatory, o.separator, o.rejectDuplicates, defaultValue, check, o.group)
+	return value, parser.applyOptions(o, err)
+}
+
+// Functional options API of ArgumentsGroup, the arguments are added to the
+// group
+
+func (argGroup *ArgumentsGroup) Int(name string, opts ...ArgOption) (*int, error) {
+	return argGroup.parser.Int(name, append(opts, InGroup(argGroup.name))...)
+}
+
+func (argGroup *ArgumentsGroup) String(name string, opts ...ArgOption) (*string, error) {
+	return argGroup.parser.String(name, append(opts, InGroup(argGroup.name))...)
+}
+
+func (argGroup *ArgumentsGroup) Bool(name string, opts ...ArgOption) (*bool, error) {
+	return argGroup.parser.Bool(name, append(opts, InGroup(argGroup.name))...)
+}
+
+func (argGroup *ArgumentsGroup) Float(name string, opts ...ArgOption) (*float64, error) {
+	return argGroup.parser.Float(name, append(opts, InGroup(argGroup.name))...)
+}
+
+func (argGroup *ArgumentsGroup) Duration(name string, opts ...ArgOption) (*time.Duration, error) {
+	return argGroup.parser.Duration(name, append(opts, InGroup(argGroup.name))...)
+}
+
+func (argGroup *ArgumentsGroup) StringSlice(name string, opts ...ArgOption) (*[]string, error) {
+	return argGroup.parser.StringSlice(name, append(opts, InGroup(argGroup.name))...)
+}
+
+func (argGroup *ArgumentsGroup) IntSlice(name string, opts ...ArgOption) (*[]int, error) {
+	return argGroup.parser.IntSlice(name, append(opts, InGroup(argGroup.name))...)
+}
+
+func (argGroup *ArgumentsGroup) StringMap(name string, opts ...ArgOption) (*map[string]string, error) {
+	return argGroup.parser.StringMap(name, append(opts, InGroup(argGroup.name))...)
+}
+
+func (argGroup *ArgumentsGroup) IntMap(name string, opts ...ArgOption) (*map[string]int, error) {
+	return argGroup.parser.IntMap(name, append(opts, InGroup(argGroup.name))...)
+}
@@ -0,0 +1,326 @@
+package argparse
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOptions(t *testing.T) {
+	parser, err := NewArgParser("prog", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	group, err := parser.AddArgumentsGroup("output", "", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	name, err := parser.String("--name", Short('n'), Help("user name"), Default("x"), Choices("x", "y"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	count, err := parser.Int("--count", Check(IntArgCheckFunc(func(i int) bool { return i > 0 })), Default(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	level, err := parser.Int("--level", Action(ActionStoreConst), Const(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ratio, err := parser.Float("--ratio", Default(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wait, err := parser.Duration("--wait", Unit(time.Second), Default(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tags, err := parser.StringSlice("--tag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	env, err := parser.StringMap("--env", Separator(":"), RejectDuplicates(), Check(func(key string, value string) bool { return key != "" }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	quiet, err := group.Bool("--quiet", Short('q'))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// mandatory bools take a value
+	// a mandatory bool takes a value
+	_, err = group.Bool("--verbose", Required())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = parser.Parse([]string{"prog", "-n", "y", "--level", "--ratio", "0.5", "--wait", "5", "--tag", "a", "--tag", "b", "--env", "k:v", "--verbose", "true"})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if *name != "y" || *count != 1 || *level != 3 || *ratio != 0.5 || *wait != 5*time.Second || *quiet {
+		t.Errorf("got name=%s count=%d level=%d ratio=%g wait=%s quiet=%t", *name, *count, *level, *ratio, *wait, *quiet)
+	}
+	if !reflect.DeepEqual(*tags, []string{"a", "b"}) || !reflect.DeepEqual(*env, map[string]string{"k": "v"}) {
+		t.Errorf("got tags=%v env=%v", *tags, *env)
+	}
+
+	err = parser.Parse([]string{"prog", "--verbose", "false"})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if *name != "x" || *ratio != 2 || *wait != time.Minute || *level != 0 {
+		t.Errorf("got name=%s ratio=%g wait=%s level=%d, want the defaults", *name, *ratio, *wait, *level)
+	}
+
+	if quietArg, _ := parser.Argument("--quiet"); quietArg.Shortcut() != 'q' || parser.Groups()[0].Arguments()[0] != quietArg {
+		t.Errorf("--quiet is not in the output group")
+	}
+	for _, args := range [][]string{
+		{"prog", "--verbose", "1", "--count", "0"},
+		{"prog", "--verbose", "1", "-n", "z"},
+		{"prog", "--verbose", "1", "--env", ":v"},
+		{"prog", "--verbose", "1", "--env", "k:1,k:2"},
+		{"prog"},
+	} {
+		if err := parser.Parse(args); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
+
+func TestOptionsInvalid(t *testing.T) {
+	parser, err := NewArgParser("prog", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		define func() error
+		reason string
+	}{
+		{func() error { _, err := parser.Int("--a", Default("1")); return err }, "Default value of argument --a must be int, not string"},
+		{func() error { _, err := parser.String("--b", Const(1)); return err }, "Const value of argument --b must be string, not int"},
+		{func() error { _, err := parser.Float("--c", Default(int64(1))); return err }, "must be float64, not int64"},
+		{func() error { _, err := parser.Int("--d", Check(func(s string) bool { return true })); return err }, "must be func(int) bool"},
+		{func() error { _, err := parser.String("--e", Choices("a", 1)); return err }, "Choices of argument --e must be string, not int"},
+		{func() error {
+			_, err := parser.String("--f", Choices("a"), Check(func(i int) bool { return true }))
+			return err
+		}, "must be func(string) bool"},
+		{func() error {
+			_, err := parser.IntMap("--g", Check(func(k string, v string) bool { return true }))
+			return err
+		}, "must be func(string, int) bool"},
+		{func() error { _, err := parser.StringSlice("--h", Default([]int{1})); return err }, "must be []string, not []int"},
+	}
+
+	for _, test := range tests {
+		err := test.define()
+		if err == nil || !strings.Contains(err.Error(), test.reason) {
+			t.Errorf("got error %v, want %q", err, test.reason)
+		}
+	}
+
+	// nothing was defined
+	if args := parser.Arguments(); len(args) != 1 {
+		t.Errorf("got %d arguments, want only --help", len(args))
+	}
+}
+
+func TestBoolDefault(t *testing.T) {
+	tests := []struct {
+		opts  []ArgOption
+		args  []string
+		want  bool
+		valid bool
+	}{
+		{nil, []string{"prog"}, false, true},
+		{nil, []string{"prog", "--color"}, true, true},
+		{[]ArgOption{Default(true)}, []string{"prog"}, true, true},
+		{[]ArgOption{Default(true)}, []string{"prog", "--color"}, false, true},
+		{[]ArgOption{Action(ActionStoreFalse)}, []string{"prog"}, true, true},
+		{[]ArgOption{Default(true), Action(ActionStoreFalse)}, []string{"prog", "--color"}, false, true},
+		{[]ArgOption{Default(true), Action(ActionStoreTrue)}, nil, false, false},
+		{[]ArgOption{Default(false), Action(ActionStoreFalse)}, nil, false, false},
+		// a mandatory bool takes its value, whatever the action
+		{[]ArgOption{Required(), Default(false), Action(ActionStoreFalse)}, []string{"prog", "--color", "true"}, true, true},
+		{[]ArgOption{Required(), Default(true)}, []string{"prog", "--color", "0"}, false, true},
+	}
+
+	for i, test := range tests {
+		parser, err := NewArgParser("prog", "", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		color, err := parser.Bool("--color", test.opts...)
+		if !test.valid {
+			if err == nil {
+				t.Errorf("%d: expected an error for a default stored by the action", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%d: unexpected error %s", i, err)
+			continue
+		}
+
+		err = parser.Parse(test.args)
+		if err != nil {
+			t.Errorf("%d: unexpected error %s", i, err)
+		} else if *color != test.want {
+			t.Errorf("%d %v: got %t, want %t", i, test.args, *color, test.want)
+		}
+	}
+}
+
+func TestAppendAction(t *testing.T) {
+	tests := []struct {
+		name string
+		add  func(parser *ArgParser, opts ...ArgOption) error
+	}{
+		{"StringSlice", func(parser *ArgParser, opts ...ArgOption) error {
+			_, err := parser.StringSlice("--values", opts...)
+			return err
+		}},
+		{"IntSlice", func(parser *ArgParser, opts ...ArgOption) error {
+			_, err := parser.IntSlice("--values", opts...)
+			return err
+		}},
+		{"StringMap", func(parser *ArgParser, opts ...ArgOption) error {
+			_, err := parser.StringMap("--values", opts...)
+			return err
+		}},
+		{"IntMap", func(parser *ArgParser, opts ...ArgOption) error {
+			_, err := parser.IntMap("--values", opts...)
+			return err
+		}},
+	}
+
+	for _, test := range tests {
+		for _, action := range []int{ActionStoreValue, ActionStoreConst, ActionStoreTrue} {
+			parser, err := NewArgParser("prog", "", true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.add(parser, Action(action)) == nil {
+				t.Errorf("%s: expected an error for action %d", test.name, action)
+			}
+		}
+
+		parser, err := NewArgParser("prog", "", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := test.add(parser, Action(ActionAppend)); err != nil {
+			t.Errorf("%s: unexpected error %s", test.name, err)
+		}
+	}
+}
+
+func TestCompletionOption(t *testing.T) {
+	complete := func(toComplete string) []string { return []string{"main"} }
+
+	parser, err := NewArgParser("prog", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = parser.String("--branch", Completion(complete))
+	if err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+
+	_, err = parser.Bool("--force", Completion(complete))
+	if err == nil {
+		t.Errorf("expected an error for the completion of a flag")
+	}
+
+	_, err = parser.Int("--verbose", Action(ActionIncrement), Completion(complete))
+	if err == nil {
+		t.Errorf("expected an error for the completion of a counter")
+	}
+
+	// a mandatory bool takes a value
+	_, err = parser.Bool("--color", Required(), Completion(complete))
+	if err != nil {
+		t.Errorf("unexpected error %s", err)
+	}
+}
+
+func TestOptionsRetry(t *testing.T) {
+	complete := func(toComplete string) []string { return nil }
+
+	parser, err := NewArgParser("prog", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	group, err := parser.AddArgumentsGroup("output", "", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the invalid options are rejected before the argument is added, so it
+	// can be defined again without them
+	tests := []struct {
+		name   string
+		define func(opts ...ArgOption) error
+		bad    ArgOption
+		reason string
+	}{
+		{"--force", func(opts ...ArgOption) error { _, err := parser.Bool("--force", opts...); return err }, Completion(complete), "Argument --force does not take a value"},
+		{"--verbose", func(opts ...ArgOption) error {
+			_, err := parser.Int("--verbose", append(opts, Action(ActionIncrement))...)
+			return err
+		}, Completion(complete), "Argument --verbose does not take a value"},
+		{"--raw", func(opts ...ArgOption) error { _, err := group.Bool("--raw", opts...); return err }, Completion(complete), "Argument --raw does not take a value"},
+		{"file", func(opts ...ArgOption) error { _, err := parser.String("file", opts...); return err }, Env("FILE"), "Positional argument file cannot be read from environment"},
+		{"ports", func(opts ...ArgOption) error { _, err := parser.IntSlice("ports", opts...); return err }, Env("PORTS"), "Positional argument ports cannot be read from environment"},
+	}
+
+	for _, test := range tests {
+		err := test.define(test.bad)
+		if err == nil || err.Error() != test.reason {
+			t.Errorf("%s: got error %v, want %q", test.name, err, test.reason)
+		}
+		if err := test.define(); err != nil {
+			t.Errorf("%s: unexpected error %s defining it again", test.name, err)
+		}
+	}
+}
+
+func TestChoicesAndCheck(t *testing.T) {
+	even := func(value int) bool { return value%2 == 0 }
+
+	tests := []struct {
+		value string
+		valid bool
+	}{
+		{"2", true},
+		{"4", true},
+		{"3", false},
+		{"6", false},
+	}
+
+	for _, test := range tests {
+		parser, err := NewArgParser("prog", "", true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = parser.Int("--level", Choices(1, 2, 3, 4), Check(even))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = parser.Parse([]string{"prog", "--level", test.value})
+		if test.valid && err != nil {
+			t.Errorf("%s: unexpected error %s", test.value, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: expected an error", test.value)
+		}
+	}
+}
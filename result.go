@@ -0,0 +1,270 @@
+package argparse
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Result holds the values of one ParseArgs call, it does not change after
+// it is returned and it is safe to use from multiple goroutines
+type Result struct {
+	parser     string
+	values     map[string]interface{}
+	sources    map[string]Source
+	shortcuts  map[rune]string // shortcut to key of values
+	subcommand string
+	sub        *Result
+	remainder  []string
+	help       string
+}
+
+// clone returns a copy of the parser with new values, so it can be parsed
+// without changing the pointers returned when the arguments were added
+func (parser *ArgParser) clone() *ArgParser {
+	c := new(ArgParser)
+	*c = *parser
+
+	cloned := map[*Argument]*Argument{}
+
+	c.arguments = make([]*Argument, 0, len(parser.arguments))
+	for _, arg := range parser.arguments {
+		argClone := new(Argument)
+		*argClone = *arg
+		argClone.val = arg.val.clone()
+		argClone.parser = c
+
+		if arg.action == ActionHelp {
+			if help, ok := argClone.val.(stringValue); ok {
+				c.helpArgument = help.value
+			}
+		}
+
+		cloned[arg] = argClone
+		c.arguments = append(c.arguments, argClone)
+	}
+
+	c.posArguments = make([]*Argument, 0, len(parser.posArguments))
+	for _, arg := range parser.posArguments {
+		c.posArguments = append(c.posArguments, cloned[arg])
+	}
+
+	c.groups = map[string]*ArgumentsGroup{}
+	for key, argGroup := range parser.groups {
+		groupClone := new(ArgumentsGroup)
+		*groupClone = *argGroup
+		groupClone.parser = c
+		groupClone.arguments = make([]*Argument, 0, len(argGroup.arguments))
+		for _, arg := range argGroup.arguments {
+			groupClone.arguments = append(groupClone.arguments, cloned[arg])
+		}
+		c.groups[key] = groupClone
+	}
+
+	c.subparsers = map[string]*ArgParser{}
+	for key, subparser := range parser.subparsers {
+		subClone := subparser.clone()
+		subClone.parent = c
+		c.subparsers[key] = subClone
+	}
+
+	if parser.configArgument != nil {
+		c.configArgument = cloned[parser.configArgument]
+	}
+
+	c.selectedSubparser = new(string)
+	c.bindings = nil
+	c.remainder = nil
+	c.config = nil
+	c.parentConfig = nil
+	c.sources = nil
+
+	return c
+}
+
+// newResult returns the values of a parsed clone and of its selected
+// subparsers
+func newResult(parser *ArgParser) *Result {
+	result := new(Result)
+	result.parser = parser.path()
+	result.values = map[string]interface{}{}
+	result.sources = map[string]Source{}
+	result.shortcuts = map[rune]string{}
+	result.remainder = parser.remainder
+
+	for _, arg := range parser.arguments {
+		if arg.action == ActionHelp {
+			continue
+		}
+		key := arg.name
+		if key == "" {
+			key = "-" + string(arg.shortcut)
+		}
+		result.values[key] = arg.val.getRaw()
+		result.sources[key] = parser.sources[arg]
+		if arg.shortcut != NOSHORTCUT {
+			result.shortcuts[arg.shortcut] = key
+		}
+	}
+
+	if *(parser.selectedSubparser) != "" {
+		subparser, ok := parser.getSubparser(*(parser.selectedSubparser))
+		if ok {
+			result.subcommand = subparser.name
+			result.sub = newResult(subparser)
+		}
+	}
+
+	return result
+}
+
+// ParseArgs parses the arguments like Parse but returns the values in a
+// Result, the parser and the pointers returned by the AddX methods are not
+// changed, so the same parser can be used by multiple goroutines. The
+// Result holds copies of the values added with AddValue, and the bindings of
+// AddStruct are not run. If the help argument was given, the Result only
+// contains the help message and ErrHelp is returned.
+func (parser *ArgParser) ParseArgs(arguments []string) (*Result, error) {
+	c := parser.clone()
+
+	err := c.parse(arguments)
+
+	if errors.Is(err, ErrHelp) {
+		result := &Result{parser: c.path(), values: map[string]interface{}{}, sources: map[string]Source{}, shortcuts: map[rune]string{}}
+		if c.helpArgument != nil {
+			result.help = *(c.helpArgument)
+		}
+		return result, err
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return newResult(c), nil
+}
+
+// key returns the key of the argument in values, the name can be given
+// with or without prefix, Ex: "--port", "port", or the shortcut, Ex: "-p"
+func (result *Result) key(name string) (string, bool) {
+	lowerName := strings.ToLower(name)
+
+	if _, ok := result.values[lowerName]; ok {
+		return lowerName, true
+	}
+
+	if _, ok := result.values["--"+lowerName]; ok {
+		return "--" + lowerName, true
+	}
+
+	shortcut := strings.TrimPrefix(name, "-")
+	if len(shortcut) == 1 {
+		key, ok := result.shortcuts[rune(shortcut[0])]
+		return key, ok
+	}
+
+	return "", false
+}
+
+// Get returns the value of the argument, the name can be given with or
+// without prefix, Ex: "--port", "port", or the shortcut, Ex: "-p"
+func (result *Result) Get(name string) (interface{}, bool) {
+	key, ok := result.key(name)
+	if !ok {
+		return nil, false
+	}
+	return result.values[key], true
+}
+
+// IsSet checks if the argument was given in the command line, environment
+// or config file
+func (result *Result) IsSet(name string) bool {
+	return result.Source(name) != SourceDefault
+}
+
+// Source returns where the value of the argument came from, SourceDefault
+// if the argument does not exist or was not given
+func (result *Result) Source(name string) Source {
+	key, ok := result.key(name)
+	if !ok {
+		return SourceDefault
+	}
+	return result.sources[key]
+}
+
+func (result *Result) GetInt(name string) (int, bool) {
+	val, ok := result.Get(name)
+	i, ok2 := val.(int)
+	return i, ok && ok2
+}
+
+func (result *Result) GetString(name string) (string, bool) {
+	val, ok := result.Get(name)
+	s, ok2 := val.(string)
+	return s, ok && ok2
+}
+
+func (result *Result) GetBool(name string) (bool, bool) {
+	val, ok := result.Get(name)
+	b, ok2 := val.(bool)
+	return b, ok && ok2
+}
+
+func (result *Result) GetFloat(name string) (float64, bool) {
+	val, ok := result.Get(name)
+	f, ok2 := val.(float64)
+	return f, ok && ok2
+}
+
+func (result *Result) GetDuration(name string) (time.Duration, bool) {
+	val, ok := result.Get(name)
+	d, ok2 := val.(time.Duration)
+	return d, ok && ok2
+}
+
+func (result *Result) GetStrings(name string) ([]string, bool) {
+	val, ok := result.Get(name)
+	s, ok2 := val.([]string)
+	return append([]string{}, s...), ok && ok2
+}
+
+func (result *Result) GetInts(name string) ([]int, bool) {
+	val, ok := result.Get(name)
+	i, ok2 := val.([]int)
+	return append([]int{}, i...), ok && ok2
+}
+
+// Parser returns the path of the parser of the result, Ex: tool remote add
+func (result *Result) Parser() string {
+	return result.parser
+}
+
+// Subcommand returns the name of the selected subcommand, or "" if none
+func (result *Result) Subcommand() string {
+	return result.subcommand
+}
+
+// Sub returns the result of the selected subcommand, or nil if none
+func (result *Result) Sub() *Result {
+	return result.sub
+}
+
+// Commands returns the names of the selected subcommands, Ex: remote add
+func (result *Result) Commands() []string {
+	commands := []string{}
+	for r := result; r.sub != nil; r = r.sub {
+		commands = append(commands, r.subcommand)
+	}
+	return commands
+}
+
+// Remainder returns the arguments after the -- terminator that were not
+// consumed by positional arguments
+func (result *Result) Remainder() []string {
+	return append([]string{}, result.remainder...)
+}
+
+// Help returns the help message if the help argument was given
+func (result *Result) Help() string {
+	return result.help
+}
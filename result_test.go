@@ -0,0 +1,198 @@
+package argparse
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newRemoteParser(t *testing.T) (*ArgParser, *int) {
+	t.Helper()
+	parser, err := NewArgParser("tool", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	level, err := parser.Int("--level", Short('l'), Default(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = parser.AddBool("", 'q', "", false, ActionStoreTrue, false, false, nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote, err := parser.AddSubparser("remote", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	add, err := remote.AddSubparser("add", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = add.String("url")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = add.Duration("--timeout", Default(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = add.StringSlice("--tag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parser, level
+}
+
+func TestParseArgs(t *testing.T) {
+	parser, level := newRemoteParser(t)
+
+	result, err := parser.ParseArgs([]string{"tool", "-l", "3", "-q", "remote", "add", "--tag", "a", "--tag", "b", "https://example.com", "--", "x"})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+
+	// the parser is not changed
+	if *level != 1 || parser.GetSelectedSubparser() != "" {
+		t.Errorf("got level=%d subcommand=%q after ParseArgs", *level, parser.GetSelectedSubparser())
+	}
+
+	if got, ok := result.GetInt("level"); !ok || got != 3 {
+		t.Errorf("got level %d, %t", got, ok)
+	}
+	if got, ok := result.GetBool("q"); !ok || !got {
+		t.Errorf("got -q %t, %t", got, ok)
+	}
+	if _, ok := result.GetString("--level"); ok {
+		t.Errorf("--level is not a string")
+	}
+	if _, ok := result.Get("--missing"); ok {
+		t.Errorf("got a value of --missing")
+	}
+	if result.Parser() != "tool" || result.Subcommand() != "remote" || !reflect.DeepEqual(result.Commands(), []string{"remote", "add"}) {
+		t.Errorf("got parser=%q subcommand=%q commands=%v", result.Parser(), result.Subcommand(), result.Commands())
+	}
+
+	add := result.Sub().Sub()
+	if add.Parser() != "tool remote add" || add.Sub() != nil || add.Subcommand() != "" {
+		t.Errorf("got the result of %q", add.Parser())
+	}
+	if url, _ := add.GetString("URL"); url != "https://example.com" {
+		t.Errorf("got url %q", url)
+	}
+	if timeout, _ := add.GetDuration("timeout"); timeout != time.Second {
+		t.Errorf("got timeout %s", timeout)
+	}
+	tags, _ := add.GetStrings("--tag")
+	if !reflect.DeepEqual(tags, []string{"a", "b"}) || !reflect.DeepEqual(add.Remainder(), []string{"x"}) {
+		t.Errorf("got tags=%v remainder=%v", tags, add.Remainder())
+	}
+
+	// the result is a copy
+	tags[0] = "changed"
+	if again, _ := add.GetStrings("--tag"); again[0] != "a" {
+		t.Errorf("the result was changed through a returned slice")
+	}
+}
+
+func TestParseArgsErrors(t *testing.T) {
+	parser, _ := newRemoteParser(t)
+
+	result, err := parser.ParseArgs([]string{"tool", "remote", "add", "-h"})
+	if !errors.Is(err, ErrHelp) || !strings.HasPrefix(result.Help(), "Usage: tool remote add ") {
+		t.Errorf("got error %v and help %q", err, result.Help())
+	}
+	if *parser.GetHelpArgument() != "" {
+		t.Errorf("the help argument of the parser was set")
+	}
+
+	result, err = parser.ParseArgs([]string{"tool", "--level", "x"})
+	var invalid *InvalidValueError
+	if !errors.As(err, &invalid) || result != nil {
+		t.Errorf("got error %v and result %v", err, result)
+	}
+}
+
+func TestParseArgsConcurrent(t *testing.T) {
+	parser, _ := newRemoteParser(t)
+
+	var wg sync.WaitGroup
+	failures := make(chan string, 16)
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			url := fmt.Sprintf("https://%d.example.com", i)
+			result, err := parser.ParseArgs([]string{"tool", "-l", fmt.Sprint(i), "remote", "add", url})
+			if err != nil {
+				failures <- err.Error()
+				return
+			}
+			level, _ := result.GetInt("level")
+			got, _ := result.Sub().Sub().GetString("url")
+			if level != i || got != url {
+				failures <- fmt.Sprintf("call %d got level %d and url %s", i, level, got)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(failures)
+
+	for failure := range failures {
+		t.Error(failure)
+	}
+}
+
+func TestParseArgsClonableValue(t *testing.T) {
+	parser, err := NewArgParser("prog", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	given := &semver{1, 0}
+	err = parser.AddValue("--version", 'v', "", false, given, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			want := fmt.Sprintf("2.%d", i)
+			result, err := parser.ParseArgs([]string{"prog", "--version", want})
+			if err != nil {
+				t.Errorf("unexpected error %s", err)
+				return
+			}
+
+			val, _ := result.Get("--version")
+			if got := val.(Value).String(); got != want {
+				t.Errorf("got version %s, want %s", got, want)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if given.String() != "1.0" {
+		t.Errorf("ParseArgs changed the given value to %s", given)
+	}
+
+	// the copies start from the default, not from the value of Parse
+	err = parser.Parse([]string{"prog", "-v", "3.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := parser.ParseArgs([]string{"prog"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if val, _ := result.Get("-v"); val.(Value).String() != "1.0" {
+		t.Errorf("got default version %s, want 1.0", val)
+	}
+}
@@ -0,0 +1,344 @@
+package argparse
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+/*
+Struct tags understood by AddStruct:
+
+	arg      name and/or shortcut, Ex: "--port,-p", "-p" or "file" for a
+	         positional. By default the field name in kebab case (--max-size).
+	         "-" skips the field.
+	help     description of the argument or subcommand
+	default  default value, by default the current value of the field
+	required "true" to make the argument mandatory
+	group    arguments group, it is created if it does not exist
+	env      environment variable read if the argument is not given
+	negate   "true" in a bool field to make the flag set it to false, its
+	         default is true, Ex: NoColor bool `arg:"--no-color" negate:"true"`
+	choices  comma separated allowed values of string and int fields
+	cmd      name of the subcommand of a struct or *struct field
+*/
+
+var (
+	durationType    = reflect.TypeOf(time.Duration(0))
+	stringSliceType = reflect.TypeOf([]string{})
+	intSliceType    = reflect.TypeOf([]int{})
+	stringMapType   = reflect.TypeOf(map[string]string{})
+	intMapType      = reflect.TypeOf(map[string]int{})
+)
+
+// NewArgParserFromStruct creates a parser with the arguments defined by the
+// fields of the struct pointed by dest (see AddStruct). The struct is filled
+// in each successful Parse.
+func NewArgParserFromStruct(dest interface{}) (*ArgParser, error) {
+	parser, err := NewArgParser("", "", true)
+	if err != nil {
+		return nil, err
+	}
+
+	err = parser.AddStruct(dest)
+	if err != nil {
+		return nil, err
+	}
+
+	return parser, nil
+}
+
+// AddStruct adds the arguments defined by the tags of the fields of the
+// struct pointed by dest. Fields of type int, string, bool, float64,
+// time.Duration, []string, []int, map[string]string and map[string]int, or
+// types defined from them (type Level int), are supported, bool fields are
+// flags. Fields with the cmd tag are subparsers.
+func (parser *ArgParser) AddStruct(dest interface{}) error {
+	ptr := reflect.ValueOf(dest)
+	if ptr.Kind() != reflect.Ptr || ptr.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Destination must be a pointer to a struct, not %T", dest)
+	}
+
+	structValue := ptr.Elem()
+	structType := structValue.Type()
+
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if field.PkgPath != "" {
+			// unexported
+			continue
+		}
+
+		var err error
+		if cmd, ok := field.Tag.Lookup("cmd"); ok {
+			err = parser.addStructSubparser(cmd, field, structValue.Field(i))
+		} else {
+			err = parser.addStructField(field, structValue.Field(i))
+		}
+
+		if err != nil {
+			return fmt.Errorf("Field %s: %s", field.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func (parser *ArgParser) addStructSubparser(cmd string, field reflect.StructField, fieldValue reflect.Value) error {
+	if cmd == "" {
+		cmd = kebabCase(field.Name)
+	}
+
+	subparser, err := parser.AddSubparser(cmd, field.Tag.Get("help"), true)
+	if err != nil {
+		return err
+	}
+
+	switch {
+		case fieldValue.Kind() == reflect.Struct:
+			return subparser.AddStruct(fieldValue.Addr().Interface())
+
+		case fieldValue.Kind() == reflect.Ptr && fieldValue.Type().Elem().Kind() == reflect.Struct:
+			// the field is only set if the subcommand is selected
+			subDest := reflect.New(fieldValue.Type().Elem())
+			if !fieldValue.IsNil() {
+				subDest.Elem().Set(fieldValue.Elem())
+			}
+
+			parser.bindings = append(parser.bindings, func() {
+				if parser.GetSelectedSubparser() == subparser.name {
+					fieldValue.Set(subDest)
+				} else {
+					fieldValue.Set(reflect.Zero(fieldValue.Type()))
+				}
+			})
+			return subparser.AddStruct(subDest.Interface())
+	}
+
+	return fmt.Errorf("cmd tag is only valid in struct or *struct fields")
+}
+
+func (parser *ArgParser) addStructField(field reflect.StructField, fieldValue reflect.Value) error {
+	argTag := field.Tag.Get("arg")
+	if argTag == "-" {
+		return nil
+	}
+
+	name, shortcut, err := parseArgTag(argTag, field.Name)
+	if err != nil {
+		return err
+	}
+
+	description := field.Tag.Get("help")
+	group := field.Tag.Get("group")
+	mandatory := field.Tag.Get("required") == "true"
+
+	if group != "" && !parser.existsGroup(group) {
+		_, err = parser.AddArgumentsGroup(group, "", false, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	defaultValue, hasDefault := field.Tag.Lookup("default")
+	if !hasDefault {
+		defaultValue = ""
+	}
+
+	var choices []string
+	if choicesTag := field.Tag.Get("choices"); choicesTag != "" {
+		choices = strings.Split(choicesTag, ",")
+	}
+
+	var ptr interface{}
+
+	switch {
+		case fieldValue.Type() == durationType:
+			def := time.Duration(fieldValue.Int())
+			if hasDefault {
+				def, err = time.ParseDuration(defaultValue)
+			}
+			if err == nil {
+				ptr, err = parser.AddDuration(name, shortcut, description, mandatory, ActionStoreValue, def, 0, 0, nil, group)
+			}
+
+		case fieldValue.Kind() == reflect.Int:
+			def := int(fieldValue.Int())
+			if hasDefault {
+				def, err = strconv.Atoi(defaultValue)
+			}
+			if err == nil && choices != nil {
+				var intChoices []int
+				intChoices, err = atoiSlice(choices)
+				if err == nil {
+					ptr, err = parser.AddIntChoices(name, shortcut, description, mandatory, ActionStoreValue, def, 0, intChoices, nil, group)
+				}
+			} else if err == nil {
+				ptr, err = parser.AddInt(name, shortcut, description, mandatory, ActionStoreValue, def, 0, nil, group)
+			}
+
+		case fieldValue.Kind() == reflect.String:
+			def := fieldValue.String()
+			if hasDefault {
+				def = defaultValue
+			}
+			if choices != nil {
+				ptr, err = parser.AddStringChoices(name, shortcut, description, mandatory, ActionStoreValue, def, "", choices, nil, group)
+			} else {
+				ptr, err = parser.AddString(name, shortcut, description, mandatory, ActionStoreValue, def, "", nil, group)
+			}
+
+		case fieldValue.Kind() == reflect.Bool:
+			// the flag sets the field to true, or to false if negated
+			negate := field.Tag.Get("negate") == "true"
+			def := fieldValue.Bool() || negate
+			if hasDefault {
+				def, err = strconv.ParseBool(defaultValue)
+			}
+			if err == nil && def != negate {
+				err = fmt.Errorf("Default value %t of a bool field requires negate:\"%t\"", def, def)
+			}
+			if err == nil {
+				action := ActionStoreTrue
+				if negate {
+					action = ActionStoreFalse
+				}
+				ptr, err = parser.AddBool(name, shortcut, description, mandatory, action, def, false, nil, group)
+			}
+
+		case fieldValue.Kind() == reflect.Float64:
+			def := fieldValue.Float()
+			if hasDefault {
+				def, err = strconv.ParseFloat(defaultValue, 64)
+			}
+			if err == nil {
+				ptr, err = parser.AddFloat(name, shortcut, description, mandatory, ActionStoreValue, def, 0, nil, group)
+			}
+
+		case fieldValue.Type().ConvertibleTo(stringSliceType):
+			def := fieldValue.Convert(stringSliceType).Interface().([]string)
+			if hasDefault {
+				def = strings.Split(defaultValue, ",")
+			}
+			ptr, err = parser.AddStringSlice(name, shortcut, description, mandatory, def, nil, group)
+
+		case fieldValue.Type().ConvertibleTo(intSliceType):
+			def := fieldValue.Convert(intSliceType).Interface().([]int)
+			if hasDefault {
+				def, err = atoiSlice(strings.Split(defaultValue, ","))
+			}
+			if err == nil {
+				ptr, err = parser.AddIntSlice(name, shortcut, description, mandatory, def, nil, group)
+			}
+
+		case fieldValue.Type().ConvertibleTo(stringMapType):
+			def := fieldValue.Convert(stringMapType).Interface().(map[string]string)
+			if hasDefault {
+				def = map[string]string{}
+				var pairs [][2]string
+				pairs, err = splitPairs(defaultValue, "=")
+				for _, pair := range pairs {
+					def[pair[0]] = pair[1]
+				}
+			}
+			if err == nil {
+				ptr, err = parser.AddStringMap(name, shortcut, description, mandatory, "", false, def, nil, group)
+			}
+
+		case fieldValue.Type().ConvertibleTo(intMapType):
+			def := fieldValue.Convert(intMapType).Interface().(map[string]int)
+			if hasDefault {
+				def = map[string]int{}
+				var pairs [][2]string
+				pairs, err = splitPairs(defaultValue, "=")
+				for _, pair := range pairs {
+					if err != nil {
+						break
+					}
+					def[pair[0]], err = strconv.Atoi(pair[1])
+				}
+			}
+			if err == nil {
+				ptr, err = parser.AddIntMap(name, shortcut, description, mandatory, "", false, def, nil, group)
+			}
+
+		default:
+			return fmt.Errorf("Type %s is not supported", fieldValue.Type())
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if env := field.Tag.Get("env"); env != "" {
+		parser.arguments[len(parser.arguments)-1].env = env
+	}
+
+	// the field may be of a type defined from the type of the value
+	src := reflect.ValueOf(ptr).Elem()
+	fieldValue.Set(src.Convert(fieldValue.Type()))
+	parser.bindings = append(parser.bindings, func() {
+		fieldValue.Set(src.Convert(fieldValue.Type()))
+	})
+
+	return nil
+}
+
+// parseArgTag parses an arg tag as "--name,-n", "-n" or "name"
+func parseArgTag(tag string, fieldName string) (string, rune, error) {
+	name := ""
+	shortcut := NOSHORTCUT
+
+	if tag == "" {
+		return "--" + kebabCase(fieldName), NOSHORTCUT, nil
+	}
+
+	for _, part := range strings.Split(tag, ",") {
+		part = strings.TrimSpace(part)
+
+		if len(part) == 2 && part[0] == '-' {
+			shortcut = rune(part[1])
+		} else if name == "" {
+			name = part
+		} else {
+			return "", NOSHORTCUT, fmt.Errorf("Invalid arg tag %q", tag)
+		}
+	}
+
+	return name, shortcut, nil
+}
+
+// kebabCase converts a field name to kebab case, Ex: MaxSize to max-size
+// and ServerURL to server-url
+func kebabCase(name string) string {
+	var kebab strings.Builder
+	runes := []rune(name)
+
+	for i, char := range runes {
+		if unicode.IsUpper(char) && i > 0 {
+			prevLower := unicode.IsLower(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (nextLower && unicode.IsUpper(runes[i-1])) {
+				kebab.WriteRune('-')
+			}
+		}
+		kebab.WriteRune(unicode.ToLower(char))
+	}
+
+	return kebab.String()
+}
+
+func atoiSlice(values []string) ([]int, error) {
+	ints := make([]int, 0, len(values))
+	for _, value := range values {
+		i, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return nil, err
+		}
+		ints = append(ints, i)
+	}
+	return ints, nil
+}
@@ -0,0 +1,281 @@
+package argparse
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestKebabCase(t *testing.T) {
+	for name, kebab := range map[string]string{
+		"Port":      "port",
+		"MaxSize":   "max-size",
+		"ServerURL": "server-url",
+		"URLPath":   "url-path",
+		"HTTP2Only": "http2only",
+		"A":         "a",
+	} {
+		if got := kebabCase(name); got != kebab {
+			t.Errorf("kebabCase(%q) = %q, want %q", name, got, kebab)
+		}
+	}
+}
+
+func TestParseArgTag(t *testing.T) {
+	tests := []struct {
+		tag      string
+		name     string
+		shortcut rune
+	}{
+		{"", "--max-size", NOSHORTCUT},
+		{"--port,-p", "--port", 'p'},
+		{"-p, --port", "--port", 'p'},
+		{"-p", "", 'p'},
+		{"file", "file", NOSHORTCUT},
+	}
+
+	for _, test := range tests {
+		name, shortcut, err := parseArgTag(test.tag, "MaxSize")
+		if err != nil {
+			t.Errorf("%q: unexpected error %s", test.tag, err)
+		} else if name != test.name || shortcut != test.shortcut {
+			t.Errorf("%q: got %q %q, want %q %q", test.tag, name, shortcut, test.name, test.shortcut)
+		}
+	}
+
+	if _, _, err := parseArgTag("--port,--number", "Port"); err == nil {
+		t.Errorf("expected an error for two names")
+	}
+}
+
+type remoteAddOptions struct {
+	URL   string `arg:"url" help:"remote url"`
+	Fetch bool   `arg:"-f"`
+}
+
+type remoteOptions struct {
+	Verbose bool              `arg:"--verbose,-v"`
+	Add     *remoteAddOptions `cmd:""`
+}
+
+type statusOptions struct {
+	Short bool `arg:"--short,-s"`
+}
+
+type toolOptions struct {
+	Config  string        `arg:"--config,-c" help:"config file" default:"tool.json"`
+	Level   int           `choices:"1,2,3" default:"2"`
+	Mode    string        `choices:"fast,slow" group:"tuning"`
+	Ratio   float64       `group:"tuning"`
+	Timeout time.Duration `default:"30s"`
+	Tags    []string      `arg:"--tag,-t"`
+	Ports   []int         `arg:"--port" default:"80,443"`
+	Labels  map[string]string
+	Limits  map[string]int `default:"cpu=2"`
+	Skipped string         `arg:"-"`
+	hidden  string
+	Status  statusOptions  `cmd:"status" help:"show the status"`
+	Remote  *remoteOptions `cmd:""`
+}
+
+func TestAddStruct(t *testing.T) {
+	opts := toolOptions{Ratio: 0.5, Skipped: "kept", hidden: "kept"}
+	parser, err := NewArgParserFromStruct(&opts)
+	if err != nil {
+		t.Fatalf("NewArgParserFromStruct: %s", err)
+	}
+
+	// the fields hold the defaults before parsing
+	if opts.Config != "tool.json" || opts.Level != 2 || opts.Ratio != 0.5 || opts.Timeout != 30*time.Second {
+		t.Errorf("got defaults %+v", opts)
+	}
+
+	err = parser.Parse([]string{"tool", "-c", "a.json", "--level", "3", "--mode", "slow", "--timeout", "1m",
+		"-t", "x", "--tag", "y", "--port", "8080", "--labels", "env=prod", "--limits", "mem=512", "status", "-s"})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	want := toolOptions{
+		Config:  "a.json",
+		Level:   3,
+		Mode:    "slow",
+		Ratio:   0.5,
+		Timeout: time.Minute,
+		Tags:    []string{"x", "y"},
+		Ports:   []int{8080},
+		Labels:  map[string]string{"env": "prod"},
+		Limits:  map[string]int{"mem": 512},
+		Skipped: "kept",
+		hidden:  "kept",
+		Status:  statusOptions{Short: true},
+	}
+	if !reflect.DeepEqual(opts, want) {
+		t.Errorf("got %+v\nwant %+v", opts, want)
+	}
+
+	// a pointer subcommand is only set when it is selected
+	err = parser.Parse([]string{"tool", "remote", "add", "-f", "https://example.com"})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if opts.Remote == nil || opts.Remote.Add == nil || *opts.Remote.Add != (remoteAddOptions{URL: "https://example.com", Fetch: true}) {
+		t.Errorf("got remote %+v", opts.Remote)
+	}
+	if opts.Config != "tool.json" || !reflect.DeepEqual(opts.Ports, []int{80, 443}) || opts.Limits["cpu"] != 2 {
+		t.Errorf("defaults not restored: %+v", opts)
+	}
+
+	err = parser.Parse([]string{"tool", "remote", "-v"})
+	if err != nil {
+		t.Fatalf("unexpected error %s", err)
+	}
+	if opts.Remote == nil || !opts.Remote.Verbose || opts.Remote.Add != nil {
+		t.Errorf("got remote %+v", opts.Remote)
+	}
+
+	// a failed Parse does not change the struct
+	err = parser.Parse([]string{"tool", "--level", "5"})
+	if err == nil {
+		t.Fatalf("expected an error for a level out of choices")
+	}
+	if opts.Remote == nil || opts.Level != 2 {
+		t.Errorf("the struct changed after a failed Parse: %+v", opts)
+	}
+
+	if config, _ := parser.Argument("--config"); config.Shortcut() != 'c' || config.Description() != "config file" {
+		t.Errorf("got --config %+v", *config)
+	}
+	if groups := parser.Groups(); len(groups) != 1 || len(groups[0].Arguments()) != 2 {
+		t.Errorf("got groups %v", groups)
+	}
+}
+
+func TestAddStructInvalid(t *testing.T) {
+	tests := map[string]interface{}{
+		"not a pointer": struct{ Port int }{},
+		"not a struct":  new(int),
+		"bad default": &struct {
+			Port int `default:"http"`
+		}{},
+		"bad choices": &struct {
+			Level int `choices:"1,two"`
+		}{},
+		"bad duration": &struct {
+			Timeout time.Duration `default:"soon"`
+		}{},
+		"cmd of a string": &struct {
+			Name string `cmd:""`
+		}{},
+		"two names": &struct {
+			Port int `arg:"--port,--number"`
+		}{},
+		"unsupported": &struct{ Ratio float32 }{},
+		"repeated": &struct {
+			A, B int `arg:"--n"`
+		}{},
+	}
+
+	for name, dest := range tests {
+		if _, err := NewArgParserFromStruct(dest); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+type level int
+
+type mode string
+
+type names []string
+
+type labels map[string]string
+
+func TestAddStructNamedTypes(t *testing.T) {
+	type options struct {
+		Level  level  `arg:"--level" default:"1"`
+		Mode   mode   `arg:"--mode" choices:"fast,slow" default:"fast"`
+		Names  names  `arg:"--names"`
+		Labels labels `arg:"--labels"`
+	}
+
+	tests := []struct {
+		args []string
+		want options
+	}{
+		{[]string{"prog"}, options{Level: 1, Mode: "fast", Names: names{}, Labels: labels{}}},
+		{[]string{"prog", "--level", "3", "--mode", "slow"}, options{Level: 3, Mode: "slow", Names: names{}, Labels: labels{}}},
+		{[]string{"prog", "--names", "a", "--names", "b", "--labels", "k=v"}, options{Level: 1, Mode: "fast", Names: names{"a", "b"}, Labels: labels{"k": "v"}}},
+	}
+
+	for _, test := range tests {
+		var opts options
+		parser, err := NewArgParserFromStruct(&opts)
+		if err != nil {
+			t.Fatalf("NewArgParserFromStruct: %s", err)
+		}
+
+		err = parser.Parse(test.args)
+		if err != nil {
+			t.Errorf("%v: unexpected error %s", test.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(opts, test.want) {
+			t.Errorf("%v: got %+v, want %+v", test.args, opts, test.want)
+		}
+	}
+}
+
+func TestAddStructBool(t *testing.T) {
+	type options struct {
+		Color   bool `arg:"--color"`
+		NoCache bool `arg:"--no-cache" negate:"true"`
+	}
+
+	tests := []struct {
+		args    []string
+		color   bool
+		noCache bool
+	}{
+		{[]string{"prog"}, false, true},
+		{[]string{"prog", "--color"}, true, true},
+		{[]string{"prog", "--no-cache"}, false, false},
+	}
+
+	for _, test := range tests {
+		var opts options
+		parser, err := NewArgParserFromStruct(&opts)
+		if err != nil {
+			t.Fatalf("NewArgParserFromStruct: %s", err)
+		}
+
+		err = parser.Parse(test.args)
+		if err != nil {
+			t.Errorf("%v: unexpected error %s", test.args, err)
+			continue
+		}
+		if opts.Color != test.color || opts.NoCache != test.noCache {
+			t.Errorf("%v: got color=%t no-cache=%t, want color=%t no-cache=%t", test.args, opts.Color, opts.NoCache, test.color, test.noCache)
+		}
+	}
+}
+
+func TestAddStructBoolDefaultWithoutNegate(t *testing.T) {
+	tests := []interface{}{
+		&struct {
+			Color bool `default:"true"`
+		}{},
+		&struct {
+			Color bool `negate:"true" default:"false"`
+		}{},
+		&struct {
+			Color bool
+		}{Color: true},
+	}
+
+	for _, dest := range tests {
+		_, err := NewArgParserFromStruct(dest)
+		if err == nil {
+			t.Errorf("%+v: expected an error for a default inconsistent with negate", dest)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+# bash completion for tool
+_tool_completion() {
+    local cur prev word path i pos skip terminated
+    COMPREPLY=()
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    prev="${COMP_WORDS[COMP_CWORD-1]}"
+    path='tool'
+    pos=0
+    skip=0
+    terminated=0
+
+    for ((i = 1; i < COMP_CWORD; i++)); do
+        word="${COMP_WORDS[i]}"
+        if [[ $terminated -eq 1 ]]; then
+            pos=$((pos + 1))
+            continue
+        fi
+        if [[ "$word" == "=" ]]; then
+            skip=1
+            continue
+        fi
+        if [[ $skip -eq 1 ]]; then
+            skip=0
+            continue
+        fi
+        if [[ "$word" == "--" ]]; then
+            terminated=1
+            continue
+        fi
+        case "$path" in
+        'tool')
+            case "$word" in
+            --output|-o|--token)
+                skip=1 ;;
+            'remote')
+                path='tool remote'
+                pos=0 ;;
+            'status')
+                path='tool status'
+                pos=0 ;;
+            -*)
+                ;;
+            *)
+                pos=$((pos + 1)) ;;
+            esac ;;
+        'tool remote')
+            case "$word" in
+            --timeout)
+                skip=1 ;;
+            'add')
+                path='tool remote add'
+                pos=0 ;;
+            -*)
+                ;;
+            *)
+                pos=$((pos + 1)) ;;
+            esac ;;
+        'tool remote add')
+            case "$word" in
+            --tag|-t)
+                skip=1 ;;
+            -*)
+                ;;
+            *)
+                pos=$((pos + 1)) ;;
+            esac ;;
+        'tool status')
+            case "$word" in
+            -*)
+                ;;
+            *)
+                pos=$((pos + 1)) ;;
+            esac ;;
+        esac
+    done
+
+    if [[ "$cur" == "=" ]]; then
+        cur=""
+        skip=1
+    elif [[ "$prev" == "=" ]]; then
+        prev="${COMP_WORDS[COMP_CWORD-2]}"
+        skip=1
+    fi
+
+    case "$path" in
+    'tool')
+        if [[ $skip -eq 1 ]]; then
+            case "$prev" in
+            --output|-o)
+                COMPREPLY=($(compgen -W 'json text yaml' -- "$cur")) ;;
+            *)
+                COMPREPLY=($(compgen -f -- "$cur")) ;;
+            esac
+            return 0
+        fi
+        if [[ $terminated -eq 0 && "$cur" == -* ]]; then
+            COMPREPLY=($(compgen -W '--help -h --verbose -v --output -o --raw --token' -- "$cur"))
+            return 0
+        fi
+        case $pos in
+        *)
+            if [[ $terminated -eq 0 ]]; then
+                COMPREPLY=($(compgen -W 'remote status' -- "$cur"))
+            else
+                COMPREPLY=($(compgen -f -- "$cur"))
+            fi ;;
+        esac ;;
+    'tool remote')
+        if [[ $skip -eq 1 ]]; then
+            case "$prev" in
+            *)
+                COMPREPLY=($(compgen -f -- "$cur")) ;;
+            esac
+            return 0
+        fi
+        if [[ $terminated -eq 0 && "$cur" == -* ]]; then
+            COMPREPLY=($(compgen -W '--help -h --timeout' -- "$cur"))
+            return 0
+        fi
+        case $pos in
+        *)
+            if [[ $terminated -eq 0 ]]; then
+                COMPREPLY=($(compgen -W 'add' -- "$cur"))
+            else
+                COMPREPLY=($(compgen -f -- "$cur"))
+            fi ;;
+        esac ;;
+    'tool remote add')
+        if [[ $skip -eq 1 ]]; then
+            case "$prev" in
+            *)
+                COMPREPLY=($(compgen -f -- "$cur")) ;;
+            esac
+            return 0
+        fi
+        if [[ $terminated -eq 0 && "$cur" == -* ]]; then
+            COMPREPLY=($(compgen -W '--help -h --tag -t' -- "$cur"))
+            return 0
+        fi
+        case $pos in
+        0)
+            COMPREPLY=($(compgen -f -- "$cur")) ;;
+        1)
+            COMPREPLY=($(compgen -W 'git hg' -- "$cur")) ;;
+        *)
+            COMPREPLY=($(compgen -f -- "$cur")) ;;
+        esac ;;
+    'tool status')
+        if [[ $skip -eq 1 ]]; then
+            case "$prev" in
+            *)
+                COMPREPLY=($(compgen -f -- "$cur")) ;;
+            esac
+            return 0
+        fi
+        if [[ $terminated -eq 0 && "$cur" == -* ]]; then
+            COMPREPLY=($(compgen -W '--short -s' -- "$cur"))
+            return 0
+        fi
+        case $pos in
+        *)
+            COMPREPLY=($(compgen -f -- "$cur")) ;;
+        esac ;;
+    esac
+    return 0
+}
+
+complete -o filenames -F _tool_completion tool
@@ -0,0 +1,66 @@
+# fish completion for tool
+
+function __tool_path
+    set -l tokens (commandline -opc)
+    set -l path 'tool'
+    set -l skip 0
+    for token in $tokens[2..-1]
+        if test $skip -eq 1
+            set skip 0
+            continue
+        end
+        if test "$token" = --
+            break
+        end
+        switch $path
+            case 'tool'
+                switch $token
+                    case --output -o --token
+                        set skip 1
+                    case 'remote'
+                        set path 'tool remote'
+                    case 'status'
+                        set path 'tool status'
+                end
+            case 'tool remote'
+                switch $token
+                    case --timeout
+                        set skip 1
+                    case 'add'
+                        set path 'tool remote add'
+                end
+            case 'tool remote add'
+                switch $token
+                    case --tag -t
+                        set skip 1
+                end
+        end
+    end
+    echo $path
+end
+
+function __tool_using_path
+    test (__tool_path) = "$argv[1]"
+end
+
+# tool
+complete -c tool -n '__tool_using_path \'tool\'' -l help -s h -d 'Print this message'
+complete -c tool -n '__tool_using_path \'tool\'' -l verbose -s v -d 'print more details'
+complete -c tool -n '__tool_using_path \'tool\'; and not __fish_contains_opt raw' -l output -s o -x -a 'json text yaml' -d 'output as'
+complete -c tool -n '__tool_using_path \'tool\'; and not __fish_contains_opt -s o output' -l raw -d 'raw output'
+complete -c tool -n '__tool_using_path \'tool\'' -l token -r -d 'api token'
+complete -c tool -n '__tool_using_path \'tool\'' -f -a 'remote' -d 'Manage remotes'
+complete -c tool -n '__tool_using_path \'tool\'' -f -a 'status' -d 'Show the status'
+
+# tool remote
+complete -c tool -n '__tool_using_path \'tool remote\'' -l help -s h -d 'Print this message'
+complete -c tool -n '__tool_using_path \'tool remote\'' -l timeout -r -d 'seconds to wait'
+complete -c tool -n '__tool_using_path \'tool remote\'' -f -a 'add' -d 'Add a remote'
+
+# tool remote add
+complete -c tool -n '__tool_using_path \'tool remote add\'' -l help -s h -d 'Print this message'
+complete -c tool -n '__tool_using_path \'tool remote add\'' -l tag -s t -r -d 'tags of the remote'
+complete -c tool -n '__tool_using_path \'tool remote add\'' -f -a 'git hg' -d 'version control'
+
+# tool status
+complete -c tool -n '__tool_using_path \'tool status\'' -l short -s s -d 'one line per file'